@@ -2,7 +2,10 @@ package main
 
 import (
 	"conveycode/compiler"
+	"conveycode/compiler/mindustry"
+	"flag"
 	"fmt"
+	"os"
 	"time"
 
 	"github.com/TwiN/go-color"
@@ -14,13 +17,50 @@ var testCases [][]string = [][]string{
 	// {"tests/print/printLine.conv", "tests/print/compiled/"},
 	// {"tests/print/printInterpelate.conv", "tests/print/compiled/"},
 	// {"tests/condition/ifStatement.conv", "tests/condition/compiled/"},
+	// {"tests/sensor/sensor.conv", "tests/sensor/compiled/"},
+	// {"tests/links/links.conv", "tests/links/compiled/"},
+	// {"tests/unit/mining.conv", "tests/unit/compiled/"},
+	// {"tests/radar/radar.conv", "tests/radar/compiled/"},
+	// {"tests/draw/bars.conv", "tests/draw/compiled/"},
+	// {"tests/world/arena.conv", "tests/world/compiled/"},
 	{"tests/prototype/proto.conv", "tests/prototype/compiled/"},
 }
 
+// Usage: conveycode [--target logic|world] [--version v6|v7|v8] [--optimize=false] [--size-report] [file.conv dest/]
+//
+// Without a file the test cases are compiled
 func main() {
+	var target = flag.String("target", "logic", "the processor the code runs on, logic or world")
+	var version = flag.String("version", mindustry.Latest.String(), "the version of Mindustry the code runs on, v6, v7 or v8")
+	var optimize = flag.Bool("optimize", true, "rewrite the program to do the same with fewer instructions")
+	var sizeReport = flag.Bool("size-report", false, "print how many instructions each function, source line and construct compiled to")
+	flag.Parse()
+
+	var options = compiler.Options{Optimize: *optimize, SizeReport: *sizeReport}
+	var err error
+	if options.Target, err = mindustry.ParseTarget(*target); err != nil {
+		fmt.Println(color.InRed(err.Error()))
+		os.Exit(2)
+	}
+
+	if options.Target.Version, err = mindustry.ParseVersion(*version); err != nil {
+		fmt.Println(color.InRed(err.Error()))
+		os.Exit(2)
+	}
+
 	fmt.Printf("\n\n---- Start %s ----\n", color.Colorize(color.Green, time.Now().Format(time.TimeOnly)))
 
+	if flag.NArg() > 0 {
+		var dest = "."
+		if flag.NArg() > 1 {
+			dest = flag.Arg(1)
+		}
+
+		compiler.CompileFile(flag.Arg(0), dest, options)
+		return
+	}
+
 	for _, testCase := range testCases {
-		compiler.CompileFile(testCase[0], testCase[1])
+		compiler.CompileFile(testCase[0], testCase[1], options)
 	}
 }
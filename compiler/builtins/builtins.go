@@ -0,0 +1,445 @@
+package builtins
+
+import (
+	"conveycode/compiler/mindustry"
+	"conveycode/compiler/parser"
+	"conveycode/compiler/utils"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type Param struct {
+	Name string
+
+	// The words an enum parameter accepts, mapped to what is written in the instruction.
+	// nil for parameters that take any expression
+	Enum map[string]string
+
+	// The value used when the argument is left out, empty if the argument is required
+	Default string
+
+	// The type of content the parameter takes, passing other content such as @copper for a unit is an error.
+	// Zero for parameters that are not content
+	Content mindustry.ContentType
+}
+
+// A function that is built into the language and compiles to a single instruction
+type Function struct {
+	// The instruction and its sub mode, as listed in mindustry.Instructions.
+	// The mode is empty for instructions that do not have one
+	Instruction string
+	Mode        string
+
+	Params []Param
+
+	// The names of the values the instruction writes to
+	Results []string
+
+	// The order of the operands after the mode, by the name of the parameter or result.
+	// Entries that are not a name are written as they are.
+	// When nil, the parameters are followed by the results
+	Layout []string
+
+	// Wether any number of arguments is accepted, the function is then constructed by hand
+	Variadic bool
+
+	// Wether the function is called on an object, which is passed as the first parameter
+	//
+	//	turret.shoot(x, y, true)
+	Method bool
+
+	// Changes the operands before they are put in the layout,
+	// for operands that depend on each other
+	Adjust func(operands map[string]string)
+}
+
+var Functions = map[string]Function{
+	"print":   {Instruction: "print", Params: values("value"), Variadic: true},
+	"println": {Instruction: "print", Variadic: true},
+	"flush":   {Instruction: "printflush", Params: values("building")},
+
+	"format":      {Instruction: "format", Params: values("value")},
+	"localeprint": {Instruction: "localeprint", Params: values("key")},
+
+	"rgba": {Instruction: "packcolor", Params: []Param{{Name: "r"}, {Name: "g"}, {Name: "b"}, {Name: "a", Default: "1"}}, Results: []string{"result"}, Layout: []string{"result", "r", "g", "b", "a"}},
+
+	"wait":  {Instruction: "wait", Params: values("seconds")},
+	"sleep": {Instruction: "wait", Params: values("milliseconds")},
+	"stop":  {Instruction: "stop"},
+	"end":   {Instruction: "end"},
+
+	"item":   {Instruction: "lookup", Mode: "item", Params: values("id"), Results: []string{"result"}, Layout: []string{"result", "id"}},
+	"liquid": {Instruction: "lookup", Mode: "liquid", Params: values("id"), Results: []string{"result"}, Layout: []string{"result", "id"}},
+	"unit":   {Instruction: "lookup", Mode: "unit", Params: values("id"), Results: []string{"result"}, Layout: []string{"result", "id"}},
+	"block":  {Instruction: "lookup", Mode: "block", Params: values("id"), Results: []string{"result"}, Layout: []string{"result", "id"}},
+
+	"read":  {Instruction: "read", Params: values("building", "address"), Results: []string{"result"}, Layout: []string{"result", "building", "address"}},
+	"write": {Instruction: "write", Params: values("building", "address", "value"), Layout: []string{"value", "building", "address"}},
+
+	//#region Unit control
+	"unit.bind":         {Instruction: "ubind", Params: []Param{{Name: "type", Content: mindustry.UnitContent}}},
+	"unit.unbind":       {Instruction: "ucontrol", Mode: "unbind"},
+	"unit.idle":         {Instruction: "ucontrol", Mode: "idle"},
+	"unit.stop":         {Instruction: "ucontrol", Mode: "stop"},
+	"unit.move":         {Instruction: "ucontrol", Mode: "move", Params: values("x", "y")},
+	"unit.approach":     {Instruction: "ucontrol", Mode: "approach", Params: values("x", "y", "radius")},
+	"unit.pathfind":     {Instruction: "ucontrol", Mode: "pathfind", Params: values("x", "y")},
+	"unit.autoPathfind": {Instruction: "ucontrol", Mode: "autoPathfind"},
+	"unit.boost":        {Instruction: "ucontrol", Mode: "boost", Params: values("enable")},
+	"unit.target":       {Instruction: "ucontrol", Mode: "target", Params: values("x", "y", "shoot")},
+	"unit.targetp":      {Instruction: "ucontrol", Mode: "targetp", Params: values("unit", "shoot")},
+	"unit.itemDrop":     {Instruction: "ucontrol", Mode: "itemDrop", Params: values("building", "amount")},
+	"unit.itemTake":     {Instruction: "ucontrol", Mode: "itemTake", Params: []Param{{Name: "building"}, {Name: "item", Content: mindustry.ItemContent}, {Name: "amount"}}},
+	"unit.payDrop":      {Instruction: "ucontrol", Mode: "payDrop"},
+	"unit.payTake":      {Instruction: "ucontrol", Mode: "payTake", Params: values("takeUnits")},
+	"unit.payEnter":     {Instruction: "ucontrol", Mode: "payEnter"},
+	"unit.mine":         {Instruction: "ucontrol", Mode: "mine", Params: values("x", "y")},
+	"unit.flag":         {Instruction: "ucontrol", Mode: "flag", Params: values("value")},
+	"unit.build":        {Instruction: "ucontrol", Mode: "build", Params: []Param{{Name: "x"}, {Name: "y"}, {Name: "block", Content: mindustry.BlockContent}, {Name: "rotation"}, {Name: "config"}}},
+	"unit.getBlock":     {Instruction: "ucontrol", Mode: "getBlock", Params: values("x", "y"), Results: []string{"type", "building", "floor"}},
+	"unit.within":       {Instruction: "ucontrol", Mode: "within", Params: values("x", "y", "radius"), Results: []string{"result"}},
+	//#endregion
+
+	//#region Drawing
+	"draw.clear":    {Instruction: "draw", Mode: "clear", Params: values("r", "g", "b")},
+	"draw.color":    {Instruction: "draw", Mode: "color", Params: []Param{{Name: "r"}, {Name: "g"}, {Name: "b"}, {Name: "a", Default: "255"}}},
+	"draw.col":      {Instruction: "draw", Mode: "col", Params: values("color")},
+	"draw.stroke":   {Instruction: "draw", Mode: "stroke", Params: values("width")},
+	"draw.line":     {Instruction: "draw", Mode: "line", Params: values("x", "y", "x2", "y2")},
+	"draw.rect":     {Instruction: "draw", Mode: "rect", Params: values("x", "y", "width", "height")},
+	"draw.lineRect": {Instruction: "draw", Mode: "lineRect", Params: values("x", "y", "width", "height")},
+	"draw.poly":     {Instruction: "draw", Mode: "poly", Params: []Param{{Name: "x"}, {Name: "y"}, {Name: "sides"}, {Name: "radius"}, {Name: "rotation", Default: "0"}}},
+	"draw.linePoly": {Instruction: "draw", Mode: "linePoly", Params: []Param{{Name: "x"}, {Name: "y"}, {Name: "sides"}, {Name: "radius"}, {Name: "rotation", Default: "0"}}},
+	"draw.triangle": {Instruction: "draw", Mode: "triangle", Params: values("x", "y", "x2", "y2", "x3", "y3")},
+	"draw.image":    {Instruction: "draw", Mode: "image", Params: []Param{{Name: "x"}, {Name: "y"}, {Name: "image"}, {Name: "size"}, {Name: "rotation", Default: "0"}}},
+	"draw.text":     {Instruction: "draw", Mode: "print", Params: []Param{{Name: "x"}, {Name: "y"}, {Name: "align", Enum: identity(textAlignments...), Default: "bottomLeft"}}},
+	"draw.flush":    {Instruction: "drawflush", Params: values("display")},
+	//#endregion
+
+	//#region Building control
+	"control.shoot":  {Instruction: "control", Mode: "shoot", Params: values("building", "x", "y", "shoot"), Method: true},
+	"control.shootp": {Instruction: "control", Mode: "shootp", Params: values("building", "unit", "shoot"), Method: true},
+	//#endregion
+
+	//#region World
+	"world.getBlock":     {Instruction: "getblock", Params: []Param{{Name: "layer", Enum: identity("floor", "ore", "block", "building")}, {Name: "x"}, {Name: "y"}}, Results: []string{"result"}, Layout: []string{"layer", "result", "x", "y"}},
+	"world.setBlock":     {Instruction: "setblock", Params: []Param{{Name: "layer", Enum: identity("floor", "ore", "block")}, {Name: "block", Content: mindustry.BlockContent}, {Name: "x"}, {Name: "y"}, {Name: "team", Default: "@derelict"}, {Name: "rotation", Default: "0"}}},
+	"world.spawn":        {Instruction: "spawn", Params: []Param{{Name: "type", Content: mindustry.UnitContent}, {Name: "x"}, {Name: "y"}, {Name: "rotation", Default: "0"}, {Name: "team", Default: "@sharded"}}, Results: []string{"unit"}},
+	"world.applyStatus":  {Instruction: "status", Params: []Param{{Name: "effect", Enum: identity(statusEffects...)}, {Name: "unit"}, {Name: "duration", Default: "10"}}, Layout: []string{"false", "effect", "unit", "duration"}},
+	"world.clearStatus":  {Instruction: "status", Params: []Param{{Name: "effect", Enum: identity(statusEffects...)}, {Name: "unit"}}, Layout: []string{"true", "effect", "unit", "0"}},
+	"world.setRule":      {Instruction: "setrule", Params: []Param{{Name: "rule", Enum: identity(globalRules...)}, {Name: "value"}}, Layout: []string{"rule", "value", "0", "0", "100", "100"}},
+	"world.setTeamRule":  {Instruction: "setrule", Params: []Param{{Name: "rule", Enum: identity(teamRules...)}, {Name: "team"}, {Name: "value"}}, Layout: []string{"rule", "value", "team", "0", "100", "100"}},
+	"world.setMapArea":   {Instruction: "setrule", Params: values("x", "y", "width", "height"), Layout: []string{"mapArea", "0", "x", "y", "width", "height"}},
+	"world.message":      {Instruction: "message", Params: []Param{{Name: "kind", Enum: identity("notify", "announce", "toast", "mission")}, {Name: "duration", Default: "3"}}},
+	"world.cutscenePan":  {Instruction: "cutscene", Mode: "pan", Params: values("x", "y", "speed")},
+	"world.cutsceneZoom": {Instruction: "cutscene", Mode: "zoom", Params: values("level")},
+	"world.cutsceneStop": {Instruction: "cutscene", Mode: "stop"},
+	"world.effect":       {Instruction: "effect", Params: []Param{{Name: "effect", Enum: identity(effects...)}, {Name: "x"}, {Name: "y"}, {Name: "rotation", Default: "0"}, {Name: "color", Default: "%ffaaff"}, {Name: "data", Default: "0"}}},
+	"world.explosion":    {Instruction: "explosion", Params: []Param{{Name: "team"}, {Name: "x"}, {Name: "y"}, {Name: "radius"}, {Name: "damage"}, {Name: "air", Default: "true"}, {Name: "ground", Default: "true"}, {Name: "pierce", Default: "false"}}},
+	"world.fetch":        {Instruction: "fetch", Params: []Param{{Name: "type", Enum: identity(fetchTypes...)}, {Name: "team"}, {Name: "index", Default: "0"}, {Name: "extra", Default: "@conveyor"}}, Results: []string{"result"}, Layout: []string{"type", "result", "team", "index", "extra"}},
+	"world.sync":         {Instruction: "sync", Params: values("variable")},
+	"world.setProp":      {Instruction: "setprop", Params: values("object", "property", "value"), Layout: []string{"property", "object", "value"}},
+	"world.makeMarker":   {Instruction: "makemarker", Params: []Param{{Name: "type", Enum: identity(markerTypes...)}, {Name: "id"}, {Name: "x"}, {Name: "y"}, {Name: "replace", Default: "true"}}},
+	"world.setMarker":    {Instruction: "setmarker", Params: []Param{{Name: "property", Enum: identity(markerProperties...)}, {Name: "id"}, {Name: "a"}, {Name: "b", Default: "0"}, {Name: "c", Default: "0"}}},
+	"world.removeMarker": {Instruction: "setmarker", Params: values("id"), Layout: []string{"remove", "id"}},
+	"world.spawnWave":    {Instruction: "spawnwave", Params: []Param{{Name: "x"}, {Name: "y"}, {Name: "natural", Default: "false"}}},
+	//#endregion
+
+	//#region Radar
+	"radar": {
+		Instruction: "radar",
+		Params:      []Param{{Name: "building"}, radarTargets[0], radarTargets[1], radarTargets[2], radarSort, radarOrder},
+		Results:     []string{"result"},
+		Layout:      []string{"target1", "target2", "target3", "sort", "building", "order", "result"},
+		Adjust:      adjustOrder,
+	},
+	"uradar": {
+		Instruction: "uradar",
+		Params:      []Param{radarTargets[0], radarTargets[1], radarTargets[2], radarSort, radarOrder},
+		Results:     []string{"result"},
+		Layout:      []string{"target1", "target2", "target3", "sort", "0", "order", "result"},
+		Adjust:      adjustOrder,
+	},
+	//#endregion
+
+	//#region Unit locate
+	"locate.building": {
+		Instruction: "ulocate",
+		Mode:        "building",
+		Params:      []Param{{Name: "group", Enum: identity(locateGroups...)}, {Name: "team", Enum: map[string]string{"ally": "false", "enemy": "true"}, Default: "false"}},
+		Results:     locateResults,
+		Layout:      []string{"group", "team", "@copper", "x", "y", "found", "building"},
+	},
+	"locate.ore": {
+		Instruction: "ulocate",
+		Mode:        "ore",
+		Params:      []Param{{Name: "ore", Content: mindustry.ItemContent}},
+		Results:     locateResults,
+		Layout:      []string{"core", "true", "ore", "x", "y", "found", "building"},
+	},
+	"locate.spawn": {
+		Instruction: "ulocate",
+		Mode:        "spawn",
+		Results:     locateResults,
+		Layout:      []string{"core", "true", "@copper", "x", "y", "found", "building"},
+	},
+	"locate.damaged": {
+		Instruction: "ulocate",
+		Mode:        "damaged",
+		Results:     locateResults,
+		Layout:      []string{"core", "true", "@copper", "x", "y", "found", "building"},
+	},
+	//#endregion
+}
+
+var radarTargets = []Param{
+	{Name: "target1", Enum: identity("any", "enemy", "ally", "player", "attacker", "flying", "boss", "ground")},
+	{Name: "target2", Enum: identity("any", "enemy", "ally", "player", "attacker", "flying", "boss", "ground"), Default: "any"},
+	{Name: "target3", Enum: identity("any", "enemy", "ally", "player", "attacker", "flying", "boss", "ground"), Default: "any"},
+}
+
+var radarSort = Param{Name: "sort", Enum: identity("distance", "health", "shield", "armor", "maxHealth"), Default: "distance"}
+var radarOrder = Param{Name: "order", Enum: map[string]string{"asc": "asc", "desc": "desc"}, Default: "asc"}
+
+// The game picks the unit with the highest sort value when the order is 1,
+// but it negates the distance so that 1 picks the closest unit
+func adjustOrder(operands map[string]string) {
+	var ascending = operands["order"] == "asc"
+	operands["order"] = fmt.Sprint(utils.If(ascending == (operands["sort"] == "distance"), 1, 0))
+}
+
+var textAlignments = []string{"center", "top", "bottom", "left", "right", "topLeft", "topRight", "bottomLeft", "bottomRight"}
+
+var statusEffects = []string{
+	"burning", "freezing", "unmoving", "slow", "wet", "muddy", "melting", "sapped", "tarred", "overdrive",
+	"overclock", "shielded", "boss", "shocked", "blasted", "corroded", "disarmed", "electrified", "invincible",
+}
+
+var globalRules = []string{
+	"currentWaveTime", "waveTimer", "waves", "wave", "waveSpacing", "waveSending", "attackMode",
+	"enemyCoreBuildRadius", "dropZoneRadius", "unitCap", "lighting", "ambientLight", "solarMultiplier",
+}
+
+var teamRules = []string{
+	"buildSpeed", "unitHealth", "unitBuildSpeed", "unitCost", "unitDamage", "blockHealth", "blockDamage", "rtsMinWeight", "rtsMinSquad",
+}
+
+var effects = []string{
+	"warn", "cross", "blockFall", "placeBlock", "placeBlockSpark", "breakBlock", "spawn", "trail", "breakProp",
+	"smokeCloud", "vapor", "hit", "hitSquare", "shootSmall", "shootBig", "smokeSmall", "smokeBig", "smokeColor",
+	"smokeSquare", "smokeSquareBig", "spark", "sparkBig", "sparkShoot", "sparkShootBig", "drill", "drillBig",
+	"lightBlock", "explosion", "smokePuff", "sparkExplosion", "crossExplosion", "wave", "bubble",
+}
+
+var markerTypes = []string{"shapeText", "point", "shape", "text", "line", "texture", "quad"}
+
+var markerProperties = []string{
+	"visibility", "toggleVisibility", "text", "flushText", "fontSize", "textHeight", "labelFlags", "texture",
+	"textureSize", "autoscale", "posi", "uvi", "colori", "pos", "endPos", "drawLayer", "color", "radius",
+	"stroke", "outline", "rotation", "shape", "arc",
+}
+
+var fetchTypes = []string{"unit", "unitCount", "player", "playerCount", "core", "coreCount", "build", "buildCount"}
+
+var locateGroups = []string{"core", "storage", "generator", "turret", "factory", "repair", "battery", "reactor"}
+var locateResults = []string{"found", "x", "y", "building"}
+
+// Returns the built-in function that is called
+//
+// A call on an object that is not a namespace, such as turret.shoot(), is a method
+// and is named after the instruction, "control.shoot"
+func Lookup(call *parser.Call) (name string, function Function, ok bool) {
+	if member, isMember := call.Callee.(*parser.Member); isMember && !IsNamespace(member.Object) {
+		for method, candidate := range Functions {
+			if candidate.Method && candidate.Instruction+"."+member.Property == method {
+				return method, candidate, true
+			}
+		}
+
+		return member.Property, Function{}, false
+	}
+
+	if name, ok = Name(call.Callee); !ok {
+		return
+	}
+
+	function, ok = Functions[name]
+	return
+}
+
+// Check if the expression is the namespace of built-in functions, such as unit or draw
+func IsNamespace(expr parser.Expr) bool {
+	var ident, ok = expr.(*parser.Ident)
+	if !ok {
+		return false
+	}
+
+	for name, function := range Functions {
+		if !function.Method && strings.HasPrefix(name, ident.Name+".") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Returns the name of the function that is called,
+// namespaced functions are joined with a dot
+//
+//	print(x)     // "print"
+//	unit.move(x) // "unit.move"
+func Name(callee parser.Expr) (string, bool) {
+	switch callee := callee.(type) {
+	case *parser.Ident:
+		return callee.Name, true
+	case *parser.Member:
+		if namespace, ok := callee.Object.(*parser.Ident); ok {
+			return namespace.Name + "." + callee.Property, true
+		}
+	}
+
+	return "", false
+}
+
+// Matches the arguments of the call to the parameters of the function.
+// Returns the argument for each parameter, which is nil when the default is used
+func (this Function) Bind(call *parser.Call) ([]parser.Expr, error) {
+	var bound = make([]parser.Expr, len(this.Params))
+
+	//? The object a method is called on is the first parameter
+	var args = call.Args
+	var receiver = 0
+	if this.Method {
+		args = append([]parser.Expr{call.Callee.(*parser.Member).Object}, args...)
+		receiver = 1
+	}
+
+	if len(args) > len(this.Params) {
+		return nil, fmt.Errorf("expected at most %d arguments but got %d", len(this.Params)-receiver, len(call.Args))
+	}
+	copy(bound, args)
+
+	for _, named := range call.Named {
+		var index = slices.IndexFunc(this.Params, func(p Param) bool { return p.Name == named.Name })
+
+		if index < 0 {
+			return nil, fmt.Errorf("there is no parameter named %s", named.Name)
+		}
+		if bound[index] != nil {
+			return nil, fmt.Errorf("%s is given more than once", named.Name)
+		}
+
+		bound[index] = named.Value
+	}
+
+	for i, param := range this.Params {
+		if bound[i] == nil && param.Default == "" {
+			return nil, fmt.Errorf("missing the argument for %s", param.Name)
+		}
+	}
+
+	return bound, nil
+}
+
+// Returns the packed color of a call to rgba with number literals as arguments,
+// which is folded instead of calling packcolor at runtime
+//
+//	rgba(1, 0.5, 0) // 0xff7f00ff
+func ConstantColor(call *parser.Call) (rgba uint32, ok bool) {
+	if name, _ := Name(call.Callee); name != "rgba" {
+		return 0, false
+	}
+
+	var function = Functions["rgba"]
+	var bound, err = function.Bind(call)
+	if err != nil {
+		return 0, false
+	}
+
+	var channels = make([]float64, len(bound))
+	for i, arg := range bound {
+		var value = function.Params[i].Default
+		if arg != nil {
+			number, isNumber := arg.(*parser.Number)
+			if !isNumber {
+				return 0, false
+			}
+			value = number.Value
+		}
+
+		if channels[i], err = strconv.ParseFloat(value, 64); err != nil {
+			return 0, false
+		}
+	}
+
+	return mindustry.PackColor(channels[0], channels[1], channels[2], channels[3]), true
+}
+
+// Returns the logic ID passed to item, liquid, unit or block when it is an integer literal,
+// the content can then be looked up by the compiler instead of at runtime
+//
+//	item(3) // ItemContent, 3
+func ConstantContent(call *parser.Call) (typ mindustry.ContentType, id int, ok bool) {
+	var name, function, found = Lookup(call)
+	if !found || function.Instruction != "lookup" || len(call.Args) != 1 {
+		return 0, 0, false
+	}
+
+	var number, isNumber = call.Args[0].(*parser.Number)
+	if !isNumber {
+		return 0, 0, false
+	}
+
+	if id, err := strconv.Atoi(number.Value); err == nil {
+		typ, _ = mindustry.ParseContentType(name)
+		return typ, id, true
+	}
+
+	return 0, 0, false
+}
+
+// Returns the definition of the instruction the function compiles to
+func (this Function) Definition() mindustry.Instruction {
+	var definition, _ = mindustry.FindInstruction(this.Instruction, this.Mode)
+	return definition
+}
+
+// The list of parameter names, used in error messages
+func (this Function) Signature() string {
+	var params = this.Params
+	if this.Method {
+		params = params[1:]
+	}
+
+	var names = make([]string, len(params))
+	for i, param := range params {
+		names[i] = param.Name
+	}
+
+	return fmt.Sprintf("(%s)", strings.Join(names, ", "))
+}
+
+//#region Utilities
+
+// Parameters that take any expression
+func values(names ...string) (params []Param) {
+	for _, name := range names {
+		params = append(params, Param{Name: name})
+	}
+
+	return params
+}
+
+// An enum where every word is written as it is
+func identity(words ...string) map[string]string {
+	var enum = make(map[string]string, len(words))
+	for _, word := range words {
+		enum[word] = word
+	}
+
+	return enum
+}
+
+//#endregion
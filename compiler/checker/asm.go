@@ -0,0 +1,45 @@
+package checker
+
+import (
+	"conveycode/compiler/mindustry"
+	"conveycode/compiler/parser"
+)
+
+// Check the instructions of an asm block against the instruction table of the target.
+// Placeholders have to be declared variables and jumps can only go to labels in the same block
+func (this *checker) asm(stmt *parser.Asm) {
+	var labels = map[string]bool{}
+
+	for _, line := range stmt.Lines {
+		if name, ok := line.Label(); ok {
+			if labels[name] {
+				this.diags.Errorf(line.Pos, "the label %s is already declared in this asm block", name)
+			}
+			labels[name] = true
+		}
+	}
+
+	for _, line := range stmt.Lines {
+		if _, ok := line.Label(); ok {
+			continue
+		}
+
+		for _, match := range parser.AsmPlaceholder.FindAllStringSubmatch(line.Text, -1) {
+			if !this.isDeclared(match[1]) {
+				this.diags.Errorf(line.Pos, "undefined variable %s, declare it before using it in asm", match[1])
+			}
+		}
+
+		//? A placeholder is always one operand, whatever the name of the variable is
+		var parts = mindustry.Fields(parser.AsmPlaceholder.ReplaceAllString(line.Text, "_"))
+
+		if err := mindustry.Validate(parts, this.target); err != nil {
+			this.diags.Errorf(line.Pos, "%s", err)
+			continue
+		}
+
+		if parts[0] == "jump" && len(parts) > 1 && !labels[parts[1]] {
+			this.diags.Errorf(line.Pos, "jumps in asm have to go to a label in the same block, %s is not one", parts[1])
+		}
+	}
+}
@@ -0,0 +1,448 @@
+package checker
+
+import (
+	"conveycode/compiler/builtins"
+	"conveycode/compiler/diagnostics"
+	"conveycode/compiler/mindustry"
+	"conveycode/compiler/parser"
+	"conveycode/compiler/utils"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type checker struct {
+	diags  *diagnostics.List
+	target mindustry.Target
+
+	// The variables declared in each scope, the innermost scope is last
+	scopes []map[string]bool
+
+	// The buildings linked to the processor by the name they are used with in the code
+	links map[string]string
+
+	// How many loops and switches the current statement is nested in
+	loops    int
+	switches int
+}
+
+// Check the program for semantic errors, such as the use of undeclared variables or unknown sensors
+func Check(program *parser.Program, target mindustry.Target, diags *diagnostics.List) {
+	var this = &checker{diags: diags, target: target, links: map[string]string{}}
+
+	this.push()
+	this.statements(program.Stmts)
+	this.pop()
+}
+
+//#region Statements
+
+func (this *checker) statement(stmt parser.Stmt) {
+	switch stmt := stmt.(type) {
+	case *parser.VarDecl:
+		if call, ok := stmt.Value.(*parser.Call); ok && len(stmt.Names) > 1 {
+			this.call(call, len(stmt.Names))
+		} else if len(stmt.Names) > 1 {
+			this.diags.Errorf(stmt.Pos, "only a function that returns %d values can be assigned to %d variables", len(stmt.Names), len(stmt.Names))
+		} else {
+			this.expression(stmt.Value)
+		}
+
+		for _, name := range stmt.Names {
+			//? An underscore throws away the value
+			if name == "_" {
+				continue
+			}
+
+			if this.isDeclared(name) {
+				this.diags.Errorf(stmt.Pos, "variable %s is already declared", name)
+			}
+			this.scopes[len(this.scopes)-1][name] = true
+		}
+
+	case *parser.Assign:
+		this.expression(stmt.Value)
+		this.assignTarget(stmt.Target)
+
+	case *parser.Link:
+		this.link(stmt)
+
+	case *parser.If:
+		this.expression(stmt.Cond)
+		this.statement(stmt.Then)
+
+		if stmt.Else != nil {
+			this.statement(stmt.Else)
+		}
+
+	case *parser.ForIn:
+		if ident, ok := stmt.Collection.(*parser.Ident); !ok || ident.Name != "links" {
+			this.diags.Errorf(stmt.Collection.Position(), "for loops can only go over links")
+		}
+
+		if this.isDeclared(stmt.Name) {
+			this.diags.Errorf(stmt.Pos, "variable %s is already declared", stmt.Name)
+		}
+
+		this.push()
+		this.scopes[len(this.scopes)-1][stmt.Name] = true
+		this.loops++
+		this.statement(stmt.Body)
+		this.loops--
+		this.pop()
+
+	case *parser.Switch:
+		this.switchStatement(stmt)
+
+	case *parser.Asm:
+		this.asm(stmt)
+
+	case *parser.Break:
+		if this.loops == 0 && this.switches == 0 {
+			this.diags.Errorf(stmt.Pos, "break can only be used inside a loop or a switch")
+		}
+
+	case *parser.Continue:
+		if this.loops == 0 {
+			this.diags.Errorf(stmt.Pos, "continue can only be used inside a loop")
+		}
+
+	case *parser.Block:
+		this.push()
+		this.statements(stmt.Stmts)
+		this.pop()
+
+	case *parser.ExprStmt:
+		if call, ok := stmt.Expr.(*parser.Call); ok {
+			this.call(call, -1)
+		} else {
+			this.expression(stmt.Expr)
+		}
+	}
+}
+
+func (this *checker) link(stmt *parser.Link) {
+	//? Links are a property of the processor, declaring them inside a block would suggest otherwise
+	if len(this.scopes) > 1 {
+		this.diags.Errorf(stmt.Pos, "buildings can only be linked at the top level of the program")
+	}
+
+	for i, name := range stmt.Names {
+		var building = stmt.Buildings[i]
+
+		if !mindustry.IsLinkName(building) {
+			this.diags.Errorf(stmt.Pos, "%s is not a building link, links are named like message1 or cell2", building)
+		}
+
+		if this.isDeclared(name) {
+			this.diags.Errorf(stmt.Pos, "%s is already declared", name)
+		}
+
+		this.links[name] = building
+	}
+}
+
+func (this *checker) switchStatement(stmt *parser.Switch) {
+	this.expression(stmt.Value)
+
+	//? Numbers are compared by value, so 1 and 1.0 are the same case
+	var seen = map[string]bool{}
+
+	this.switches++
+	for _, c := range stmt.Cases {
+		for _, value := range c.Values {
+			var key, ok = caseKey(value)
+
+			switch {
+			case !ok:
+				this.diags.Errorf(value.Position(), "case values have to be numbers, strings, content or true, false and null")
+			case seen[key]:
+				this.diags.Errorf(value.Position(), "the case %s is already handled", key)
+			}
+			seen[key] = true
+		}
+
+		this.statement(c.Body)
+	}
+
+	if stmt.Default != nil {
+		this.statement(stmt.Default)
+	}
+	this.switches--
+}
+
+// Returns the constant a case value matches, false when it is not a constant
+func caseKey(expr parser.Expr) (string, bool) {
+	switch expr := expr.(type) {
+	case *parser.String:
+		return strconv.Quote(expr.Value), true
+	case *parser.Builtin:
+		return expr.Name, true
+	case *parser.Constant:
+		return expr.Value, true
+	}
+
+	if number, ok := numberValue(expr); ok {
+		return strconv.FormatFloat(number, 'g', -1, 64), true
+	}
+
+	return "", false
+}
+
+// Returns the value of a number literal, including any signs in front of it
+func numberValue(expr parser.Expr) (float64, bool) {
+	switch expr := expr.(type) {
+	case *parser.Number:
+		if number, err := strconv.ParseFloat(expr.Value, 64); err == nil {
+			return number, true
+		}
+		if number, err := strconv.ParseInt(expr.Value, 0, 64); err == nil {
+			return float64(number), true
+		}
+	case *parser.Unary:
+		var number, ok = numberValue(expr.Operand)
+		switch {
+		case !ok:
+		case expr.Op == "-":
+			return -number, true
+		case expr.Op == "+":
+			return number, true
+		}
+	}
+
+	return 0, false
+}
+
+func (this *checker) assignTarget(target parser.Expr) {
+	switch target := target.(type) {
+	case *parser.Ident:
+		if _, ok := this.links[target.Name]; ok {
+			this.diags.Errorf(target.Pos, "cannot assign to the building %s", target.Name)
+		} else if !this.isDeclared(target.Name) {
+			this.diags.Errorf(target.Pos, "variable %s is not declared, use var %s to declare it", target.Name, target.Name)
+		}
+	case *parser.Builtin:
+		//? Writing to @counter is how mlog jumps to a calculated instruction
+		if target.Name != "@counter" {
+			this.diags.Errorf(target.Pos, "cannot assign to the built-in %s", target.Name)
+		}
+	case *parser.Member:
+		this.expression(target.Object)
+
+		var definition, ok = mindustry.ControlOf(target.Property)
+		if !ok {
+			this.diags.Errorf(target.Pos, "%s can not be controlled, only %s can be set", target.Property, strings.Join(mindustry.Controls, ", "))
+		} else if !this.target.Supports(definition.Version) {
+			this.diags.Errorf(target.Pos, "setting %s needs Mindustry %s or newer, the target is %s", definition.Mode, definition.Version, this.target.GameVersion())
+		}
+	default:
+		this.diags.Errorf(target.Position(), "cannot assign to this expression")
+	}
+}
+
+//#endregion
+
+//#region Expressions
+
+func (this *checker) expression(expr parser.Expr) {
+	switch expr := expr.(type) {
+	case *parser.Ident:
+		if this.isDeclared(expr.Name) {
+			break
+		}
+
+		if mindustry.IsLinkName(expr.Name) {
+			this.diags.Errorf(expr.Pos, "building %s is not linked, declare it with use %s", expr.Name, expr.Name)
+		} else {
+			this.diags.Errorf(expr.Pos, "undefined variable %s", expr.Name)
+		}
+
+	case *parser.Member:
+		this.expression(expr.Object)
+
+		if !mindustry.IsSensor(expr.Property) {
+			this.diags.Errorf(expr.Pos, "unknown sensor %s", expr.Property)
+		}
+
+	case *parser.Call:
+		this.call(expr, 1)
+
+	case *parser.Binary:
+		this.expression(expr.Left)
+		this.expression(expr.Right)
+
+	case *parser.Unary:
+		this.expression(expr.Operand)
+
+	case *parser.Conditional:
+		this.expression(expr.Cond)
+		this.expression(expr.Then)
+		this.expression(expr.Else)
+	}
+}
+
+// Check the call to a built-in function, results is the number of values that are used
+// or -1 when the call is a statement
+func (this *checker) call(call *parser.Call, results int) {
+	var name, function, ok = builtins.Lookup(call)
+
+	if !ok {
+		for _, arg := range call.Args {
+			this.expression(arg)
+		}
+
+		if name == "" {
+			this.diags.Errorf(call.Pos, "expression can not be called")
+		} else {
+			this.diags.Errorf(call.Pos, "unknown function %s", name)
+		}
+		return
+	}
+
+	var definition = function.Definition()
+	if definition.World && this.target.Processor != mindustry.WorldProcessor {
+		this.diags.Errorf(call.Pos, "%s can only be used on a world processor, compile with --target world", name)
+	}
+
+	//? Constant colors and content are folded, so they do not need packcolor or lookup
+	var _, isColor = builtins.ConstantColor(call)
+	var typ, id, isContent = builtins.ConstantContent(call)
+	if !this.target.Supports(definition.Version) && !isColor && !isContent {
+		this.diags.Errorf(call.Pos, "%s needs Mindustry %s or newer, the target is %s", name, definition.Version, this.target.GameVersion())
+	}
+
+	if !this.arguments(name, function, call) {
+		return
+	}
+
+	if _, ok := mindustry.ContentAt(typ, id); isContent && !ok {
+		this.diags.Errorf(call.Args[0].Position(), "there is no %s with id %d, the ids go from 0 to %d", typ, id, len(mindustry.Content[typ])-1)
+	}
+
+	//? Variables of other processors are accessed by their name
+	if name == "read" || name == "write" {
+		var bound, _ = function.Bind(call)
+		if str, ok := bound[1].(*parser.String); ok && !this.target.Supports(mindustry.V8) {
+			this.diags.Errorf(str.Pos, "accessing the variables of a processor needs Mindustry %s or newer, the target is %s", mindustry.V8, this.target.GameVersion())
+		}
+	}
+
+	if results >= 0 && results != len(function.Results) {
+		if len(function.Results) == 0 {
+			this.diags.Errorf(call.Pos, "%s does not return a value", name)
+		} else {
+			this.diags.Errorf(call.Pos, "%s returns %d values (%s)", name, len(function.Results), strings.Join(function.Results, ", "))
+		}
+	}
+
+	if name != "flush" && name != "draw.flush" {
+		return
+	}
+
+	//? mlog reads a string as text, not as the name of the building
+	var bound, _ = function.Bind(call)
+	if str, ok := bound[0].(*parser.String); ok {
+		this.diags.Errorf(str.Pos, "%s expects a building, use %s instead of a string", name, str.Value)
+	}
+}
+
+// Check the arguments against the parameters of the function, returns false if they do not match
+func (this *checker) arguments(name string, function builtins.Function, call *parser.Call) bool {
+	if function.Variadic {
+		for _, arg := range call.Args {
+			this.expression(arg)
+		}
+
+		if len(call.Named) > 0 {
+			this.diags.Errorf(call.Named[0].Pos, "%s does not take named arguments", name)
+			return false
+		}
+
+		if len(call.Args) < len(function.Params) {
+			this.diags.Errorf(call.Pos, "%s expects at least %d arguments", name, len(function.Params))
+			return false
+		}
+
+		return true
+	}
+
+	var bound, err = function.Bind(call)
+	if err != nil {
+		this.diags.Errorf(call.Pos, "%s%s: %s", name, function.Signature(), err)
+		return false
+	}
+
+	for i, param := range function.Params {
+		switch {
+		case bound[i] == nil:
+		case param.Enum != nil:
+			this.enum(param, bound[i])
+		default:
+			this.expression(bound[i])
+			this.content(param, bound[i])
+		}
+	}
+
+	return true
+}
+
+// Enum arguments are written as a plain word, such as enemy or distance
+func (this *checker) enum(param builtins.Param, arg parser.Expr) {
+	if ident, ok := arg.(*parser.Ident); ok {
+		if _, ok := param.Enum[ident.Name]; ok {
+			return
+		}
+	}
+
+	var words = slices.Sorted(maps.Keys(param.Enum))
+	this.diags.Errorf(arg.Position(), "%s has to be one of %s", param.Name, strings.Join(words, ", "))
+}
+
+// Content passed to a parameter that takes another type of content, such as unit.bind(@copper)
+//
+// Built-ins that are not content, such as @unit, are only known at runtime and are not checked
+func (this *checker) content(param builtins.Param, arg parser.Expr) {
+	var builtin, ok = arg.(*parser.Builtin)
+	if param.Content == 0 || !ok {
+		return
+	}
+
+	if typ, ok := mindustry.FindContent(builtin.Name); ok && typ != param.Content {
+		this.diags.Errorf(builtin.Pos, "%s expects %s, but %s is %s", param.Name, article(param.Content), builtin.Name, article(typ))
+	}
+}
+
+// Returns the content type with a or an in front of it
+func article(typ mindustry.ContentType) string {
+	return utils.If(typ == mindustry.ItemContent, "an ", "a ") + typ.String()
+}
+
+//#endregion
+
+//#region Scopes
+
+func (this *checker) push() {
+	this.scopes = append(this.scopes, map[string]bool{})
+}
+
+func (this *checker) pop() {
+	this.scopes = this.scopes[:len(this.scopes)-1]
+}
+
+// Variables are global in mlog, so a name is declared if any of the enclosing scopes has it
+func (this *checker) isDeclared(name string) bool {
+	if _, ok := this.links[name]; ok {
+		return true
+	}
+
+	for _, scope := range this.scopes {
+		if scope[name] {
+			return true
+		}
+	}
+
+	return false
+}
+
+//#endregion
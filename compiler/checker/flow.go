@@ -0,0 +1,64 @@
+package checker
+
+import (
+	"conveycode/compiler/builtins"
+	"conveycode/compiler/parser"
+)
+
+// Check the statements of a block in order,
+// the first statement after one that never lets execution continue gets a warning
+func (this *checker) statements(stmts []parser.Stmt) {
+	var reason = ""
+	var warned = false
+
+	for _, stmt := range stmts {
+		//? Links do not compile to instructions, so they can be anywhere
+		if _, isLink := stmt.(*parser.Link); reason != "" && !warned && !isLink {
+			this.diags.Warnf(stmt.Position(), "unreachable code, %s", reason)
+			warned = true
+		}
+
+		this.statement(stmt)
+
+		if reason == "" {
+			reason = exits(stmt)
+		}
+	}
+}
+
+// Returns why execution never continues after the statement, or an empty string when it does
+//
+//	end()          // "end() restarts the program before it"
+//	if x { break } // ""
+func exits(stmt parser.Stmt) string {
+	switch stmt := stmt.(type) {
+	case *parser.Break:
+		return "break leaves the loop before it"
+	case *parser.Continue:
+		return "continue goes to the next iteration before it"
+	case *parser.ExprStmt:
+		var call, ok = stmt.Expr.(*parser.Call)
+		if !ok {
+			return ""
+		}
+
+		switch name, _ := builtins.Name(call.Callee); name {
+		case "end":
+			return "end() restarts the program before it"
+		case "stop":
+			return "stop() halts the processor before it"
+		}
+	case *parser.Block:
+		for _, inner := range stmt.Stmts {
+			if reason := exits(inner); reason != "" {
+				return reason
+			}
+		}
+	case *parser.If:
+		if stmt.Else != nil && exits(stmt.Then) != "" && exits(stmt.Else) != "" {
+			return "every branch of the if before it leaves"
+		}
+	}
+
+	return ""
+}
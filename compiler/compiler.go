@@ -1,7 +1,13 @@
 package compiler
 
 import (
-	"conveycode/compiler/lexer"
+	"conveycode/compiler/checker"
+	"conveycode/compiler/constructor"
+	"conveycode/compiler/diagnostics"
+	"conveycode/compiler/ir"
+	"conveycode/compiler/mindustry"
+	"conveycode/compiler/optimizer"
+	"conveycode/compiler/parser"
 	"conveycode/compiler/tokenizer"
 	"conveycode/compiler/utils"
 	"fmt"
@@ -9,15 +15,71 @@ import (
 	"github.com/TwiN/go-color"
 )
 
+// Settings that change how a program is compiled, the zero value compiles for a regular processor
+type Options struct {
+	Target mindustry.Target
+
+	// Wether the optimizer rewrites the program to do the same with fewer instructions
+	Optimize bool
+
+	// Wether CompileFile prints how many instructions each function, source line and construct compiled to
+	SizeReport bool
+
+	// The optimizer passes to run instead of all of them, so tests can check a single pass
+	passes []optimizer.Pass
+}
+
+// Facts about a compiled program that are shown after compiling a file
+type Stats struct {
+	// The number of instructions the optimizer removed
+	Removed int
+
+	Size SizeReport
+}
+
+// Compile the source code to mlog instructions
+//
+// The instructions are nil when any of the diagnostics is an error
+func Compile(content []rune, options Options) (instructions []string, diags diagnostics.List) {
+	instructions, _, diags = compileTokens(tokenizer.Tokenize(content), options)
+	return instructions, diags
+}
+
+func compileTokens(tokens tokenizer.TokenList, options Options) (instructions []string, stats Stats, diags diagnostics.List) {
+	var program = parser.Parse(tokens, &diags)
+	checker.Check(program, options.Target, &diags)
+
+	if diags.HasErrors() {
+		return nil, stats, diags
+	}
+
+	var code = constructor.Construct(program, options.Target, &diags)
+	if options.Optimize {
+		var passes = options.passes
+		if passes == nil {
+			passes = optimizer.Passes
+		}
+		stats.Removed = optimizer.Optimize(code, passes)
+	}
+
+	stats.Size = measure(code)
+
+	checkSize(code, stats.Size, &diags)
+	if diags.HasErrors() {
+		return nil, stats, diags
+	}
+
+	return ir.Emit(code), stats, diags
+}
+
 // Compile a .conv file to .mlog
 //
-//	compiler.CompileFile("foo/bar/file.conv", "dest/")
-func CompileFile(sourceFilePath string, dest string) {
+//	compiler.CompileFile("foo/bar/file.conv", "dest/", compiler.Options{})
+func CompileFile(sourceFilePath string, dest string, options Options) {
 	fmt.Printf("File %s\n", color.InYellow(sourceFilePath))
 
 	// tools.CursorTests(utils.GetFileRunes(sourceFilePath))
 
-	/*instructions*/
 	var tokens tokenizer.TokenList = tokenizer.Tokenize(utils.GetFileRunes(sourceFilePath))
 
 	//? Debug logging
@@ -31,14 +93,30 @@ func CompileFile(sourceFilePath string, dest string) {
 		fmt.Print(color.InUnderline(token.ColoredValue()) + " ")
 	}
 
-	fmt.Printf("\n\n-- %s --\n", color.InBlue("Lexer"))
-	var lx = lexer.Lex(tokens)
-	var block, ok = <-lx.Blocks
+	var instructions, stats, diags = compileTokens(tokens, options)
+
+	fmt.Printf("\n\n-- %s --\n", color.InBlue("Diagnostics"))
+	for _, diag := range diags {
+		fmt.Println(diag.String())
+	}
+
+	if options.SizeReport && stats.Size.Total > 0 {
+		fmt.Printf("\n-- %s --\n%s", color.InBlue("Size"), stats.Size)
+	}
+
+	if diags.HasErrors() {
+		fmt.Println(color.InRed("Compilation failed"))
+		return
+	}
+
+	fmt.Printf("\n-- %s --\n", color.InBlue("Instructions"))
+	for i, instruction := range instructions {
+		fmt.Printf("%s %s\n", color.InGray(i), instruction)
+	}
 
-	for ok {
-		fmt.Println(block.String())
-		block, ok = <-lx.Blocks
+	if stats.Removed > 0 {
+		fmt.Printf("\n%s\n", color.InGray(fmt.Sprintf("The optimizer removed %d instructions", stats.Removed)))
 	}
 
-	// utils.WriteFile(utils.GetFileName(sourceFilePath), dest, instructionLines)
+	utils.WriteFile(utils.GetFileName(sourceFilePath), dest, instructions)
 }
@@ -0,0 +1,698 @@
+package compiler
+
+import (
+	"conveycode/compiler/builtins"
+	"conveycode/compiler/diagnostics"
+	"conveycode/compiler/ir"
+	"conveycode/compiler/mindustry"
+	"conveycode/compiler/optimizer"
+	"conveycode/compiler/tokenizer"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// A source and the instructions it compiles to
+type outputCase struct {
+	source   string
+	expected []string
+}
+
+// Compile the source and report when it has diagnostics or compiles to other instructions
+func expectOutput(t *testing.T, options Options, source string, expected ...string) Stats {
+	t.Helper()
+
+	var tokens = tokenizer.Tokenize([]rune(source))
+	var instructions, stats, diags = compileTokens(tokens, options)
+
+	if len(diags) > 0 {
+		t.Errorf("%q: unexpected diagnostics %v", source, diags)
+	} else if !slices.Equal(instructions, expected) {
+		t.Errorf("%q:\n got %q\nwant %q", source, instructions, expected)
+	}
+
+	return stats
+}
+
+// Tokenize the source and report when the tokens before the end of the file differ,
+// each expected token is written as its type and value
+//
+//	expectTokens(t, "@x - 1", "Builtin @x", "Operator -", "Number 1")
+func expectTokens(t *testing.T, source string, expected ...string) {
+	t.Helper()
+
+	var tokens []string
+	for _, token := range tokenizer.Tokenize([]rune(source)) {
+		if token.Typ != tokenizer.EOF {
+			tokens = append(tokens, token.Typ.String()+" "+string(token.Val))
+		}
+	}
+
+	if !slices.Equal(tokens, expected) {
+		t.Errorf("%q:\n got %q\nwant %q", source, tokens, expected)
+	}
+}
+
+// Compile each source and report the ones that do not have an error
+func expectErrors(t *testing.T, options Options, sources ...string) {
+	t.Helper()
+
+	for _, source := range sources {
+		if _, diags := Compile([]rune(source), options); !diags.HasErrors() {
+			t.Errorf("%q: expected an error", source)
+		}
+	}
+}
+
+func TestParser(t *testing.T) {
+	var testCases = []outputCase{
+		{"var a = 1\nvar b = a + 2 * 3", []string{"set a 1", "op mul __tmp0 2 3", "op add b a __tmp0"}},
+		{"var a = 1\nvar b = (a + 2) * 3", []string{"set a 1", "op add __tmp0 a 2", "op mul b __tmp0 3"}},
+		{"var a = 1\nvar b = a - 1 - 2", []string{"set a 1", "op sub __tmp0 a 1", "op sub b __tmp0 2"}},
+		{"var b = 2 ** 3 ** 2", []string{"op pow __tmp0 3 2", "op pow b 2 __tmp0"}},
+		{"var a = 1\nvar b = a < 2 && a > 0", []string{"set a 1", "op lessThan __tmp0 a 2", "op greaterThan __tmp1 a 0", "op land b __tmp0 __tmp1"}},
+	}
+
+	for _, testCase := range testCases {
+		expectOutput(t, Options{}, testCase.source, testCase.expected...)
+	}
+
+	expectErrors(t, Options{},
+		"var = 1",
+		"var a = 1 +",
+		"var a = (1 + 2",
+		"var a = 1 1",
+		"var a = 1\nvar a = 2",
+		"print(x)",
+	)
+}
+
+func TestSensors(t *testing.T) {
+	var testCases = []outputCase{
+		{"use container1\nvar a = container1.@copper", []string{"sensor a container1 @copper"}},
+		{"use cyclone1\nvar a = cyclone1.ammo + 1", []string{"sensor __tmp0 cyclone1 @ammo", "op add a __tmp0 1"}},
+		{"var a = @unit.@health", []string{"sensor a @unit @health"}},
+		{"use cyclone1\nif cyclone1.shooting {\nprint(1)\n}", []string{"sensor __tmp0 cyclone1 @shooting", "jump 0 equal __tmp0 false", "print 1"}},
+		{"use container1\nprint(container1.totalItems * 2)", []string{"sensor __tmp1 container1 @totalItems", "op mul __tmp0 __tmp1 2", "print __tmp0"}},
+	}
+
+	for _, testCase := range testCases {
+		expectOutput(t, Options{}, testCase.source, testCase.expected...)
+	}
+
+	expectErrors(t, Options{}, "use container1\nvar a = container1.@bogus", "var a = @unit.fooBar")
+}
+
+func TestBuiltinTokens(t *testing.T) {
+	expectTokens(t, "var a = @phase-fabric", "Keyword var", "Ident a", "Operator =", "Builtin @phase-fabric")
+	expectTokens(t, "@unit.@health", "Builtin @unit", "Dot .", "Builtin @health")
+	expectTokens(t, "@x - 1", "Builtin @x", "Operator -", "Number 1")
+	expectTokens(t, "@blast-compound-", "Builtin @blast-compound", "Operator -")
+
+	expectOutput(t, Options{}, "var a = @phase-fabric\nvar b = @x - 1", "set a @phase-fabric", "op sub b @x 1")
+	expectOutput(t, Options{}, "var a = @counter", "set a @counter")
+}
+
+func TestUnaryMinus(t *testing.T) {
+	var testCases = []outputCase{
+		{"var a = 1\nvar b = a-1", []string{"set a 1", "op sub b a 1"}},
+		{"var a = 1\nvar b = a -1", []string{"set a 1", "op sub b a 1"}},
+		{"var a = 1\nvar b = a - -1", []string{"set a 1", "op sub b a -1"}},
+		{"var a = 1\nvar b = -(a)", []string{"set a 1", "op sub b 0 a"}},
+		{"var b = -(-5)", []string{"set b 5"}},
+		{"var b = -0xff", []string{"set b -255"}},
+		{"var b = -2 ** 2", []string{"op pow __tmp0 2 2", "op sub b 0 __tmp0"}},
+	}
+
+	for _, testCase := range testCases {
+		expectOutput(t, Options{}, testCase.source, testCase.expected...)
+	}
+}
+
+func TestOperators(t *testing.T) {
+	expectTokens(t, "a >= b === c", "Ident a", "Operator >=", "Ident b", "Operator ===", "Ident c")
+	expectTokens(t, "a ** 2 << 1", "Ident a", "Operator **", "Number 2", "Operator <<", "Number 1")
+	expectTokens(t, "x += y // 2", "Ident x", "Operator +=", "Ident y", "Operator //", "Number 2")
+	expectTokens(t, "x = y//2", "Ident x", "Operator =", "Ident y", "Operator //", "Number 2")
+	expectTokens(t, "// c", "Comment  c")
+	expectTokens(t, "if x { // c", "Keyword if", "Ident x", "CurlyL {", "Comment  c")
+	expectTokens(t, "x++ -> ..", "Ident x", "Operator ++", "Operator ->", "Operator ..")
+
+	var testCases = []outputCase{
+		{"var a = 7\nvar b = a // 2", []string{"set a 7", "op idiv b a 2"}},
+		{"var a = 7\nvar b = (a + 1)//2 * 3", []string{"set a 7", "op add __tmp1 a 1", "op idiv __tmp0 __tmp1 2", "op mul b __tmp0 3"}},
+		{"var a = 0.5\nvar b = a || 0", []string{"set a 0.5", "op notEqual __tmp0 a 0", "op notEqual __tmp1 0 0", "op or b __tmp0 __tmp1"}},
+		{"var a = 0.5\nvar b = a && 0.5", []string{"set a 0.5", "op land b a 0.5"}},
+		{"var a = 1\nvar b = a === 1\nvar c = a != 2\nvar d = a >> 1", []string{"set a 1", "op strictEqual b a 1", "op notEqual c a 2", "op shr d a 1"}},
+		{"var a = 1\na += 2\na -= 1\na *= 3\na /= 2", []string{"set a 1", "op add a a 2", "op sub a a 1", "op mul a a 3", "op div a a 2"}},
+		{"var a = 1\na++\na--", []string{"set a 1", "op add a a 1", "op sub a a 1"}},
+	}
+
+	for _, testCase := range testCases {
+		expectOutput(t, Options{}, testCase.source, testCase.expected...)
+	}
+
+	//? -> and .. are reserved for future use, and // after a value divides instead of starting a comment
+	expectErrors(t, Options{}, "var a = 1 -> 2", "var a = 1 .. 2", "var a = 7 // seven")
+}
+
+func TestKeywords(t *testing.T) {
+	expectTokens(t, "var if else foo while_1 null", "Keyword var", "Keyword if", "Keyword else", "Ident foo", "Ident while_1", "Keyword null")
+
+	expectOutput(t, Options{}, "var c = true\nvar d = null\nvar e = false", "set c true", "set d null", "set e false")
+	expectErrors(t, Options{}, "var if = 1", "var null = 2", "var continue = 1", "func while() {}")
+}
+
+func TestStrayClosingBrace(t *testing.T) {
+	for _, source := range []string{"}", "if 1 {\n} }", "if 1 {\nprint(1)\n}\n}\nprint(2)"} {
+		var _, diags = Compile([]rune(source), Options{})
+		if len(diags) != 1 || !diags.HasErrors() {
+			t.Errorf("%q: expected an error for the extra }, got %v", source, diags)
+		}
+	}
+}
+
+func TestLinks(t *testing.T) {
+	var testCases = []outputCase{
+		{"use cell1, message1\nprint(cell1)\nflush(message1)", []string{"print cell1", "printflush message1"}},
+		{"link out = message1\nprint(1)\nflush(out)", []string{"print 1", "printflush message1"}},
+		{"for b in links {\nprint(b)\n}", []string{
+			"set __tmp0 0", "jump 0 greaterThanEq __tmp0 @links", "getlink b __tmp0", "op add __tmp0 __tmp0 1", "print b", "jump 1 always",
+		}},
+		{"for b in links {\nif b == @this {\ncontinue\n}\nprint(b)\n}", []string{
+			"set __tmp0 0", "jump 0 greaterThanEq __tmp0 @links", "getlink b __tmp0", "op add __tmp0 __tmp0 1",
+			"jump 6 notEqual b @this", "jump 1 always", "print b", "jump 1 always",
+		}},
+	}
+
+	for _, testCase := range testCases {
+		expectOutput(t, Options{}, testCase.source, testCase.expected...)
+	}
+
+	expectErrors(t, Options{},
+		"print(cell1)",
+		"flush(\"message1\")",
+		"use cell1\ncell1 = 2",
+		"if 1 {\nuse cell1\n}",
+		"use cell1\nuse cell1",
+	)
+}
+
+func TestUnitControl(t *testing.T) {
+	var testCases = []outputCase{
+		{"unit.bind(@poly)", []string{"ubind @poly"}},
+		{"unit.move(10, 20)", []string{"ucontrol move 10 20 0 0 0"}},
+		{"unit.approach(10, 20, 5)", []string{"ucontrol approach 10 20 5 0 0"}},
+		{"unit.mine(3, 4)", []string{"ucontrol mine 3 4 0 0 0"}},
+		{"use container1\nunit.itemTake(container1, @copper, 10)", []string{"ucontrol itemTake container1 @copper 10 0 0"}},
+		{"unit.flag(7)", []string{"ucontrol flag 7 0 0 0 0"}},
+		{"unit.idle()", []string{"ucontrol idle 0 0 0 0 0"}},
+		{"var near = unit.within(10, 20, 5)", []string{"ucontrol within 10 20 5 near 0"}},
+		{"var t, b, f = unit.getBlock(1, 2)", []string{"ucontrol getBlock 1 2 t b f"}},
+	}
+
+	for _, testCase := range testCases {
+		expectOutput(t, Options{}, testCase.source, testCase.expected...)
+	}
+
+	expectErrors(t, Options{}, "unit.move(1)", "unit.move(1, 2, 3)", "unit.fly(1, 2)")
+}
+
+func TestRadar(t *testing.T) {
+	var testCases = []outputCase{
+		{"use duo1\nvar u = radar(duo1, enemy, ground, sort: distance, order: asc)", []string{"radar enemy ground any distance duo1 1 u"}},
+		{"use duo1\nvar u = radar(duo1, flying)", []string{"radar flying any any distance duo1 1 u"}},
+		{"use duo1\nvar u = radar(duo1, enemy, order: desc)", []string{"radar enemy any any distance duo1 0 u"}},
+		{"var u = uradar(enemy, sort: health, order: desc)", []string{"uradar enemy any any health 0 1 u"}},
+		{"var found, x, y, core = locate.building(core, enemy)", []string{"ulocate building core true @copper x y found core"}},
+		{"var _, x, y, _ = locate.ore(@copper)", []string{"ulocate ore core true @copper x y __tmp0 __tmp1"}},
+		{"var found, x, y, b = locate.damaged()", []string{"ulocate damaged core true @copper x y found b"}},
+	}
+
+	for _, testCase := range testCases {
+		expectOutput(t, Options{}, testCase.source, testCase.expected...)
+	}
+
+	expectErrors(t, Options{},
+		"use duo1\nvar u = radar(duo1, nobody)",
+		"use duo1\nvar u = radar(duo1, enemy, sort: speed)",
+		"use duo1\nvar u = radar(duo1, enemy, order: up)",
+		"var a, b = locate.building(core)",
+		"var a, b, c, d = locate.building(wall)",
+	)
+}
+
+func TestDrawing(t *testing.T) {
+	expectOutput(t, Options{},
+		"use display1\ndraw.clear(1, 2, 3)\ndraw.color(255, 0, 0, 255)\ndraw.stroke(2)\ndraw.line(0, 0, 10, 10)\ndraw.rect(0, 0, 5, 5)\ndraw.poly(5, 5, 6, 3, 0)\ndraw.text(1, 1, center)\ndraw.flush(display1)",
+		"draw clear 1 2 3 0 0 0",
+		"draw color 255 0 0 255 0 0",
+		"draw stroke 2 0 0 0 0 0",
+		"draw line 0 0 10 10 0 0",
+		"draw rect 0 0 5 5 0 0",
+		"draw poly 5 5 6 3 0 0",
+		"draw print 1 1 center 0 0 0",
+		"drawflush display1",
+	)
+
+	//? The buffer holds 256 commands, so a drawflush goes in before the 257th
+	var source = "use display1\n" + strings.Repeat("draw.rect(0, 0, 1, 1)\n", 300) + "draw.flush(display1)"
+	var instructions, diags = Compile([]rune(source), Options{})
+	if len(diags) > 0 || len(instructions) != 302 || instructions[256] != "drawflush display1" || instructions[301] != "drawflush display1" {
+		t.Errorf("expected drawflush at 256 and 301, got %d instructions and %v", len(instructions), diags)
+	}
+
+	//? The number of iterations is not known, so the loop can overflow the buffer
+	_, diags = Compile([]rune("use display1\nfor b in links {\ndraw.rect(0, 0, 1, 1)\n}\ndraw.flush(display1)"), Options{})
+	if len(diags) != 1 || diags[0].Severity != diagnostics.Warning || diags[0].Pos.Line != 2 {
+		t.Errorf("expected a warning for the loop, got %v", diags)
+	}
+
+	expectErrors(t, Options{}, "draw.text(1, 1, middle)", "draw.rect(0, 0)")
+}
+
+func TestDrawsAcrossRuns(t *testing.T) {
+	var rects = func(count int) string {
+		return strings.Repeat("draw.rect(0, 0, 1, 1)\n", count)
+	}
+
+	//? Nothing is flushed, so the buffer fills up over a few runs
+	var _, diags = Compile([]rune(rects(200)), Options{})
+	if len(diags) != 1 || diags[0].Severity != diagnostics.Warning {
+		t.Errorf("expected a warning for the draws left in the buffer, got %v", diags)
+	}
+
+	//? The 200 commands after the flush are still buffered when the program starts over
+	var instructions, _ = Compile([]rune("use display1\n"+rects(100)+"draw.flush(display1)\n"+rects(200)), Options{})
+	var flushes []int
+	for i, instruction := range instructions {
+		if instruction == "drawflush display1" {
+			flushes = append(flushes, i)
+		}
+	}
+
+	if !slices.Equal(flushes, []int{56, 101}) {
+		t.Errorf("expected drawflush at 56 and 101, got %v", flushes)
+	}
+}
+
+func TestBuildingControl(t *testing.T) {
+	var testCases = []outputCase{
+		{"use switch1\nswitch1.enabled = false", []string{"control enabled switch1 false 0 0 0"}},
+		{"use sorter1\nsorter1.config = @copper", []string{"control config sorter1 @copper 0 0 0"}},
+		{"use illuminator1\nilluminator1.color = 0xff0000", []string{"control color illuminator1 0xff0000 0 0 0"}},
+		{"use duo1\nduo1.shoot(10, 20, true)", []string{"control shoot duo1 10 20 true 0"}},
+		{"use duo1\nduo1.shootp(@unit, true)", []string{"control shootp duo1 @unit true 0 0"}},
+	}
+
+	for _, testCase := range testCases {
+		expectOutput(t, Options{}, testCase.source, testCase.expected...)
+	}
+
+	expectErrors(t, Options{}, "use switch1\nswitch1.health = 5", "use duo1\nduo1.shoot(1)", "use duo1\nduo1.fire(1, 2, true)")
+
+	//? control color came with v7
+	var v6 = Options{Target: mindustry.Target{Version: mindustry.V6}}
+	expectErrors(t, v6, "use illuminator1\nilluminator1.color = 0xff0000")
+	expectOutput(t, v6, "use switch1\nswitch1.enabled = false", "control enabled switch1 false 0 0 0")
+}
+
+func TestWorldTarget(t *testing.T) {
+	var source = "var u = world.spawn(@dagger, 10, 20)\nworld.applyStatus(wet, u, 5)\nworld.setRule(waveSpacing, 60)\nworld.message(announce)"
+
+	expectOutput(t, Options{Target: mindustry.Target{Processor: mindustry.WorldProcessor}}, source,
+		"spawn @dagger 10 20 0 @sharded u",
+		"status false wet u 5",
+		"setrule waveSpacing 60 0 0 100 100",
+		"message announce 3",
+	)
+
+	//? The same code is an error on a regular processor
+	expectErrors(t, Options{}, source)
+}
+
+func TestVersionProfiles(t *testing.T) {
+	var testCases = []struct {
+		source  string
+		version mindustry.Version
+		ok      bool
+	}{
+		{"use cell1\nvar x = read(cell1, 0)", mindustry.V6, true},
+		{"use processor1\nvar x = read(processor1, \"count\")", mindustry.V7, false},
+		{"use processor1\nvar x = read(processor1, \"count\")", mindustry.V8, true},
+		{"format(10)", mindustry.V7, false},
+		{"draw.col(0)", mindustry.V6, false},
+		{"draw.col(0)", 0, true},
+		{"draw.text(0, 0)", mindustry.V7, false},
+		{"draw.text(0, 0)", mindustry.V8, true},
+	}
+
+	for _, testCase := range testCases {
+		var _, diags = Compile([]rune(testCase.source), Options{Target: mindustry.Target{Version: testCase.version}})
+
+		if diags.HasErrors() == testCase.ok {
+			t.Errorf("%q on %s: got diagnostics %v", testCase.source, testCase.version, diags)
+		}
+	}
+}
+
+func TestBuiltinsMatchInstructions(t *testing.T) {
+	for name, function := range builtins.Functions {
+		var definition, ok = mindustry.FindInstruction(function.Instruction, function.Mode)
+		if !ok {
+			t.Errorf("%s: %s %s is not in the instruction table", name, function.Instruction, function.Mode)
+			continue
+		}
+
+		var layout = function.Layout
+		if layout == nil {
+			for _, param := range function.Params {
+				layout = append(layout, param.Name)
+			}
+			layout = append(layout, function.Results...)
+		}
+
+		if len(layout) > definition.Operands() {
+			t.Errorf("%s: writes %d operands but %s takes %d", name, len(layout), function.Instruction, definition.Operands())
+			continue
+		}
+
+		//? Results have to end up in the operands the instruction writes to
+		for _, result := range function.Results {
+			var index = slices.Index(layout, result)
+			if index >= len(definition.Args) || definition.Args[index].Kind != mindustry.Out {
+				t.Errorf("%s: result %s is not written to an output of %s", name, result, function.Instruction)
+			}
+		}
+	}
+}
+
+func TestSamplesValidate(t *testing.T) {
+	var files, _ = filepath.Glob("../tests/*/*.conv")
+	var target = mindustry.Target{Processor: mindustry.WorldProcessor}
+
+	for _, file := range files {
+		var content, _ = os.ReadFile(file)
+		var instructions, diags = Compile([]rune(string(content)), Options{Target: target})
+
+		//? Some samples show syntax that is not supported yet
+		if diags.HasErrors() {
+			continue
+		}
+
+		for i, instruction := range instructions {
+			if err := mindustry.Validate(mindustry.Fields(instruction), target); err != nil {
+				t.Errorf("%s:%d %q: %s", file, i, instruction, err)
+			}
+		}
+	}
+}
+
+func TestColors(t *testing.T) {
+	var testCases = []struct {
+		outputCase
+		version mindustry.Version
+	}{
+		{outputCase{"var c = #FF8800", []string{"set c %ff8800ff"}}, 0},
+		{outputCase{"var c = #ff8800cc", []string{"set c %ff8800cc"}}, mindustry.V7},
+		{outputCase{"var c = rgba(1, 0.5, 0)", []string{"set c %ff7f00ff"}}, 0},
+		{outputCase{"var c = rgba(0, 0, 0, 0)", []string{"set c 0"}}, mindustry.V6},
+		{outputCase{"var r = 1\nvar c = rgba(r, 0, 0, a: 0.5)", []string{"set r 1", "packcolor c r 0 0 0.5"}}, 0},
+	}
+
+	for _, testCase := range testCases {
+		expectOutput(t, Options{Target: mindustry.Target{Version: testCase.version}}, testCase.source, testCase.expected...)
+	}
+
+	expectErrors(t, Options{}, "var c = #ff88")
+}
+
+func TestContentLookup(t *testing.T) {
+	var testCases = []outputCase{
+		{"var a = item(3)", []string{"set a @graphite"}},
+		{"var a = liquid(0)", []string{"set a @water"}},
+		{"unit.bind(unit(15))", []string{"ubind @flare"}},
+		{"var i = 1\nvar b = block(i)", []string{"set i 1", "lookup block b i"}},
+	}
+
+	for _, testCase := range testCases {
+		expectOutput(t, Options{}, testCase.source, testCase.expected...)
+	}
+
+	expectErrors(t, Options{}, "var a = item(99)", "unit.bind(@copper)")
+}
+
+func TestWaitAndTermination(t *testing.T) {
+	var instructions, diags = Compile([]rune("var t = 10\nsleep(250)\nsleep(t)\nwait(2)\nend()\nprint(t)"), Options{})
+	var expected = []string{"set t 10", "wait 0.25", "op div __tmp0 t 1000", "wait __tmp0", "wait 2", "end", "print t"}
+
+	if !slices.Equal(instructions, expected) {
+		t.Errorf("\n got %q\nwant %q", instructions, expected)
+	}
+
+	if len(diags) != 1 || diags[0].Severity != diagnostics.Warning || diags[0].Pos.Line != 6 {
+		t.Errorf("expected a warning for the unreachable print, got %v", diags)
+	}
+}
+
+func TestSwitch(t *testing.T) {
+	var testCases = []outputCase{
+		{
+			"var s = 0\nswitch s {\ncase 0: s = 1\ncase 1, 2: s = 2\n}",
+			[]string{
+				"set s 0",
+				"op floor __tmp0 s 0", "jump 0 notEqual __tmp0 s",
+				"jump 0 lessThan __tmp0 0", "jump 0 greaterThan __tmp0 2",
+				"op add @counter @counter __tmp0", "jump 9 always", "jump 11 always", "jump 11 always",
+				"set s 1", "jump 0 always",
+				"set s 2",
+			},
+		},
+		{
+			"var b = @router\nswitch b {\ncase @router: b = 1\ndefault: b = 2\n}",
+			[]string{
+				"set b @router",
+				"jump 3 strictEqual b @router", "jump 5 always",
+				"set b 1", "jump 0 always",
+				"set b 2",
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		expectOutput(t, Options{}, testCase.source, testCase.expected...)
+	}
+
+	expectErrors(t, Options{}, "var s = 0\nswitch s {\ncase 1, 1.0: s = 1\n}", "var s = 0\nswitch s {\ncase s: s = 1\n}")
+}
+
+func TestConditional(t *testing.T) {
+	var source = "var hp = 10\nvar n = 1 + (hp < 50 ? hp : 0)"
+
+	expectOutput(t, Options{Target: mindustry.Target{Version: mindustry.V8}}, source,
+		"set hp 10", "select __tmp0 lessThan hp 50 hp 0", "op add n 1 __tmp0")
+	expectOutput(t, Options{Target: mindustry.Target{Version: mindustry.V7}}, source,
+		"set hp 10", "jump 4 greaterThanEq hp 50", "set __tmp0 hp", "jump 5 always", "set __tmp0 0", "op add n 1 __tmp0")
+}
+
+func TestAsm(t *testing.T) {
+	var source = "link target = duo1\nvar hp = 0\nasm { sensor {hp} {target} @health }\nasm {\n    loop:\n    jump loop lessThan {hp} 10\n}"
+
+	expectOutput(t, Options{}, source, "set hp 0", "sensor hp duo1 @health", "jump 2 lessThan hp 10")
+	expectErrors(t, Options{}, "asm { sensor {hp} @unit @health }", "asm { jump 0 always }", "asm { ucontrol fly }")
+
+	//? Lines with too few operands are errors, so the constructor never sees them
+	expectErrors(t, Options{}, "asm {\njump\n}", "asm {\nloop:\njump loop\n}", "asm {\nloop:\njump loop equal 1\n}",
+		"asm {\nloop:\njump loop sometimes 1 2\n}", "asm { print }")
+}
+
+func TestClassify(t *testing.T) {
+	var testCases = map[string]ir.Kind{
+		"1": ir.Literal, "-2.5": ir.Literal, "1e3": ir.Literal, "0x1F": ir.Literal, "0b101": ir.Literal, "\"text\"": ir.Literal,
+		"@copper": ir.Literal, "@time": ir.Variable, "__tmp3": ir.Temp,
+		"inf": ir.Variable, "infinity": ir.Variable, "nan": ir.Variable, "NaN": ir.Variable, "e5": ir.Variable, "0x": ir.Variable,
+	}
+
+	for name, kind := range testCases {
+		if got := ir.Classify(name).Kind; got != kind {
+			t.Errorf("%s: got kind %d, want %d", name, got, kind)
+		}
+	}
+}
+
+func TestControlFlowGraph(t *testing.T) {
+	var program = ir.Build([]ir.Instruction{
+		ir.New(ir.Origin{}, "set", "x", "@unit"),
+		ir.Mark("loop"),
+		ir.New(ir.Origin{}, "jump", "halt", "equal", "x", "1"),
+		ir.New(ir.Origin{}, "op", "add", "__tmp0", "x", "1"),
+		ir.New(ir.Origin{}, "end"),
+		ir.Mark("halt"),
+		ir.New(ir.Origin{}, "stop"),
+	})
+
+	var blocks = program.Blocks
+	var expected = [][]*ir.Block{{blocks[1]}, {blocks[3], blocks[2]}, {blocks[0]}, nil}
+	for i, block := range blocks {
+		if !slices.Equal(block.Succs, expected[i]) {
+			t.Errorf("block %d has %d successors, want %d", i, len(block.Succs), len(expected[i]))
+		}
+	}
+
+	var op = blocks[2].Instructions[0]
+	if defs := op.Defs(); len(defs) != 1 || defs[0].Kind != ir.Temp {
+		t.Errorf("expected the op to define a temp, got %v", defs)
+	}
+	if uses := op.Uses(); len(uses) != 1 || uses[0].Name != "x" {
+		t.Errorf("expected the op to only use x, got %v", uses)
+	}
+
+	var lines = ir.Emit(program)
+	var want = []string{"set x @unit", "jump 4 equal x 1", "op add __tmp0 x 1", "end", "stop"}
+	if !slices.Equal(lines, want) {
+		t.Errorf("\n got %q\nwant %q", lines, want)
+	}
+
+	//? A jump without a condition does not get past the checker, but it must not break the graph either
+	var short = ir.Build([]ir.Instruction{ir.Mark("loop"), ir.New(ir.Origin{}, "jump", "loop")})
+	if len(short.Blocks) != 1 || !slices.Equal(short.Blocks[0].Succs, short.Blocks) {
+		t.Errorf("expected a jump without a condition to loop back to its block")
+	}
+}
+
+func TestPropagation(t *testing.T) {
+	var options = Options{Optimize: true, passes: []optimizer.Pass{optimizer.Propagate}}
+	var testCases = []outputCase{
+		{"var x = 3\nvar z = x + 5", []string{"set x 3", "set z 8"}},
+		{"var a = @time\nvar b = a\nvar c = b\nprint(c)", []string{"set a @time", "set b a", "set c a", "print a"}},
+		{"var x = 1\nif @time > 5 {\nx = 2\n}\nprint(x)", []string{"set x 1", "jump 3 lessThanEq @time 5", "set x 2", "print x"}},
+		{"var x = 1\nif @time > 5 {\nx = 1\n}\nprint(x)", []string{"set x 1", "jump 2 lessThanEq @time 5", "print 1"}},
+		{"var u = @unit\nunit.bind(@flare)\nprint(u)", []string{"set u @unit", "ubind @flare", "print u"}},
+		{"var x = 1 << -1\nvar y = 256 >> 68", []string{"set x -9223372036854776000", "set y 16"}},
+		{"var inf = 1\nif inf < 5 {\nprint(\"small\")\n}\nvar nan = 2\nprint(nan)", []string{"set inf 1", "jump 3 greaterThanEq 1 5", "print \"small\"", "set nan 2", "print 2"}},
+		{"var a = 0.5\nvar b = a || 0\nprint(b)", []string{"set a 0.5", "set __tmp0 1", "set __tmp1 0", "set b 1", "print 1"}},
+	}
+
+	for _, testCase := range testCases {
+		expectOutput(t, options, testCase.source, testCase.expected...)
+	}
+}
+
+func TestDeadCode(t *testing.T) {
+	var options = Options{Optimize: true, passes: []optimizer.Pass{optimizer.Propagate, optimizer.Eliminate}}
+	var testCases = []struct {
+		outputCase
+		removed int
+	}{
+		{outputCase{"if 1 > 2 {\nprint(1)\n}\nprint(2)", []string{"jump 1 always", "print 2"}}, 1},
+		{outputCase{"use cell1\nvar s = read(cell1, 0)\nvar u = s + 1", []string{"read s cell1 0", "op add u s 1"}}, 0},
+		{outputCase{"var a = 0.5\nvar b = a || 0", []string{"set a 0.5", "set b 1"}}, 2},
+		{outputCase{"var inf = 1\nif inf < 5 {\nprint(\"small\")\n}", []string{"set inf 1", "print \"small\""}}, 1},
+		{outputCase{"var k = 1\nswitch k {\ncase 0: print(0)\ncase 1: print(1)\ncase 2: print(2)\n}", []string{"set k 1", "jump 2 always", "print 1", "jump 0 always"}}, 10},
+	}
+
+	for _, testCase := range testCases {
+		if stats := expectOutput(t, options, testCase.source, testCase.expected...); stats.Removed != testCase.removed {
+			t.Errorf("%q: removed %d instructions, want %d", testCase.source, stats.Removed, testCase.removed)
+		}
+	}
+
+	//? Nothing in the sample reads z, but other processors can, so it stays
+	var content, _ = os.ReadFile("../tests/assignment/setAdd.conv")
+	expectOutput(t, Options{Optimize: true}, string(content), "set x 321", "set y 123.321", "set z 444.321", "set y 444.321", "set z 142627.041")
+}
+
+func TestPeephole(t *testing.T) {
+	var options = Options{Optimize: true, passes: []optimizer.Pass{optimizer.Propagate, optimizer.Eliminate, optimizer.Peephole}}
+	var testCases = []outputCase{
+		{
+			"var s = @time\nswitch s {\ncase 1: print(1)\ndefault: print(2)\n}",
+			[]string{"set s @time", "jump 4 notEqual s 1", "print 1", "jump 0 always", "print 2"},
+		},
+		{
+			"var a = @time\nif a > 5 {\nif a > 6 {\nprint(1)\n} else {\nprint(2)\n}\n} else {\nprint(3)\n}\nprint(4)",
+			[]string{
+				"set a @time", "jump 7 lessThanEq a 5", "jump 5 lessThanEq a 6",
+				"print 1", "jump 8 always", "print 2", "jump 8 always", "print 3", "print 4",
+			},
+		},
+		{"asm {\nloop:\nprint 1\njump loop always\n}", []string{"print 1"}},
+		{
+			"var s = @time\nswitch s {\ncase 0: print(0)\ncase 1: s = 1\ncase 2: print(2)\n}",
+			[]string{
+				"set s @time", "op floor __tmp0 s 0", "jump 0 notEqual __tmp0 s",
+				"jump 0 lessThan __tmp0 0", "jump 0 greaterThan __tmp0 2",
+				"op add @counter @counter __tmp0", "jump 9 always", "jump 11 always", "jump 13 always",
+				"print 0", "jump 0 always", "set s 1", "jump 0 always", "print 2",
+			},
+		},
+		{
+			"for b in links {\nswitch @time {\ncase 0: break\ncase 1: continue\n}\nprint(b)\n}",
+			[]string{
+				"set __tmp0 0", "jump 0 greaterThanEq __tmp0 @links", "getlink b __tmp0", "op add __tmp0 __tmp0 1",
+				"jump 6 equal @time 0", "jump 1 equal @time 1", "print b", "jump 1 always",
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		expectOutput(t, options, testCase.source, testCase.expected...)
+	}
+}
+
+func TestTempAllocation(t *testing.T) {
+	var options = Options{Target: mindustry.Target{Version: mindustry.V7}, Optimize: true}
+	var testCases = []outputCase{
+		{
+			"var a = @time\nvar b = (a + 1) * (a + 2)\nvar c = (a + 3) * (a + 4)\nprint(b)\nprint(c)",
+			[]string{
+				"set a @time",
+				"op add __tmp0 a 1", "op add __tmp1 a 2", "op mul b __tmp0 __tmp1",
+				"op add __tmp0 a 3", "op add __tmp1 a 4", "op mul c __tmp0 __tmp1",
+				"print b", "print c",
+			},
+		},
+		{
+			"var a = @time\nvar x = (a + 1) + (a > 3 ? a * 2 : a * 3)\nprint(x)",
+			[]string{
+				"set a @time", "op add __tmp0 a 1",
+				"jump 5 lessThanEq a 3", "op mul __tmp1 a 2", "jump 6 always", "op mul __tmp1 a 3",
+				"op add x __tmp0 __tmp1", "print x",
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		expectOutput(t, options, testCase.source, testCase.expected...)
+	}
+}
+
+func TestSizeLimit(t *testing.T) {
+	var source = strings.Repeat("print(@time)\n", mindustry.MaxInstructions)
+	if instructions, diags := Compile([]rune(source), Options{}); len(diags) > 0 || len(instructions) != mindustry.MaxInstructions {
+		t.Errorf("expected %d instructions to fit, got %d and %v", mindustry.MaxInstructions, len(instructions), diags)
+	}
+
+	var instructions, diags = Compile([]rune(source+"print(@time)"), Options{})
+	if instructions != nil || len(diags) != 1 || diags[0].Severity != diagnostics.Error || diags[0].Pos.Line != mindustry.MaxInstructions+1 {
+		t.Fatalf("expected an error at the instruction that does not fit, got %v", diags)
+	}
+
+	if !strings.Contains(diags[0].Message, "1001 instructions") || !strings.Contains(diags[0].Message, "print (1001)") {
+		t.Errorf("expected the message to list the largest function, got %q", diags[0].Message)
+	}
+
+	var size = expectOutput(t, Options{}, "var a = @time\nif a > 3 {\nprint(a)\nprint(1)\n}",
+		"set a @time", "jump 0 lessThanEq a 3", "print a", "print 1").Size
+	if size.Total != 4 || size.Functions["print"] != 2 || size.Lines[2] != 1 || size.Constructs["if"] != 1 || size.Constructs["call"] != 2 {
+		t.Errorf("unexpected size report %+v", size)
+	}
+}
+
+func TestFlushNamedArguments(t *testing.T) {
+	expectOutput(t, Options{}, "use message1\nflush(building: message1)", "printflush message1")
+	expectOutput(t, Options{}, "use display1\ndraw.clear(0, 0, 0)\ndraw.flush(display: display1)", "draw clear 0 0 0 0 0 0", "drawflush display1")
+	expectErrors(t, Options{}, "flush(building: \"message1\")", "draw.flush(display: \"display1\")")
+}
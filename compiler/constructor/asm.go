@@ -0,0 +1,42 @@
+package constructor
+
+import (
+	"conveycode/compiler/mindustry"
+	"conveycode/compiler/parser"
+)
+
+// Emit the instructions of an asm block, with the placeholders replaced by the variables
+// and the labels replaced by new unique labels
+func (this *constructor) asm(stmt *parser.Asm) {
+	var labels = map[string]string{}
+	for _, line := range stmt.Lines {
+		if name, ok := line.Label(); ok {
+			labels[name] = this.label()
+		}
+	}
+
+	for _, line := range stmt.Lines {
+		if name, ok := line.Label(); ok {
+			this.place(labels[name])
+			continue
+		}
+
+		var text = parser.AsmPlaceholder.ReplaceAllStringFunc(line.Text, func(placeholder string) string {
+			return this.variable(placeholder[1 : len(placeholder)-1])
+		})
+
+		var parts = mindustry.Fields(text)
+		switch parts[0] {
+		case "jump":
+			parts[1] = labels[parts[1]]
+		case "draw":
+			if this.draws != unknownDraws {
+				this.draws++
+			}
+		case "drawflush":
+			this.draws = 0
+		}
+
+		this.emit(parts...)
+	}
+}
@@ -1,13 +1,13 @@
 package constructor
 
 import (
-	"conveycode/compiler/utils"
-	"slices"
+	"conveycode/compiler/builtins"
+	"conveycode/compiler/mindustry"
+	"conveycode/compiler/parser"
+	"strconv"
 	"strings"
 )
 
-var operatorSymbols []string = []string{"+", "-", "*", "/"}
-
 // Get the correct operator syntax from the operator symbol that was used
 //
 //	"+" = "add"
@@ -22,50 +22,178 @@ func getOperator(operator string) string {
 		return "mul"
 	case "/":
 		return "div"
+	case "//":
+		return "idiv"
+	case "%":
+		return "mod"
+	case "**":
+		return "pow"
+	case "==":
+		return "equal"
+	case "!=":
+		return "notEqual"
+	case "===":
+		return "strictEqual"
+	case "<":
+		return "lessThan"
+	case "<=":
+		return "lessThanEq"
+	case ">":
+		return "greaterThan"
+	case ">=":
+		return "greaterThanEq"
+	case "&&":
+		return "land"
+	case "&":
+		return "and"
+	case "|":
+		return "or"
+	case "<<":
+		return "shl"
+	case ">>":
+		return "shr"
 	default:
 		return operator
 	}
 }
 
-func constructVariable(parts []string) []string {
-	var outLine []string
+// Apply a unary + or - to a number literal
+//
+//	signNumber("-", "5")    // -5
+//	signNumber("-", "-5")   // 5
+//	signNumber("-", "0xff") // -255
+func signNumber(sign string, value string) string {
+	if sign == "+" {
+		return value
+	}
 
-	idx := slices.Index(parts, "=")
-	outLine = append(outLine, "set", parts[idx-1])
-	outLine = append(outLine, parts[idx+1:]...)
+	if strings.HasPrefix(value, "-") {
+		return value[1:]
+	}
 
-	return outLine
+	//? mlog does not read negative hexadecimal numbers, so write them as decimal
+	if number, err := strconv.ParseInt(value, 0, 64); err == nil && strings.ContainsAny(value, "xX") {
+		return strconv.FormatInt(-number, 10)
+	}
+
+	return "-" + value
 }
-func constructOperation(parts []string) []string {
-	var outLine []string
-	outLine = append(outLine, "op")
 
-	idx := slices.Index(parts, "=")
-	variable := parts[:idx]
-	operand := parts[idx+1:]
+// Fold the signs in front of a number literal into the literal,
+// returns false if the expression is not a signed number literal
+//
+//	-(-5) // 5
+func signedNumber(expr parser.Expr) (string, bool) {
+	switch expr := expr.(type) {
+	case *parser.Number:
+		return expr.Value, true
+	case *parser.Unary:
+		if expr.Op == "!" {
+			return "", false
+		}
 
-	//TODO Add support for multi operational assignments
-	//* example: var z = x + y * (x - (y / x)) + y
+		if value, ok := signedNumber(expr.Operand); ok {
+			return signNumber(expr.Op, value), true
+		}
+	}
+
+	return "", false
+}
 
-	//! Currently only suppoerts single assignment
-	outLine = append(outLine, getOperator(operand[1]))
+func (this *constructor) constructVariable(dest string, value string) {
+	this.emit("set", dest, value)
+}
 
-	//? Append the variable name to the line
-	outLine = append(outLine, variable[len(variable)-1])
+// Construct the instructions needed to calculate the expression
+// and return the variable or literal that holds the result
+//
+//	var z = x + y * (x - (y / x)) + y
+func (this *constructor) constructOperation(expr parser.Expr) string {
+	switch expr := expr.(type) {
+	case *parser.Number:
+		return expr.Value
+	case *parser.String:
+		return "\"" + expr.Value + "\""
+	case *parser.Color:
+		return this.color(expr.Value)
+	case *parser.Call:
+		if rgba, ok := builtins.ConstantColor(expr); ok {
+			return mindustry.ColorLiteral(rgba, this.target.GameVersion())
+		}
 
-	//? Append the 2 operational arguments
-	outLine = append(outLine, operand[0], operand[2])
+		if typ, id, ok := builtins.ConstantContent(expr); ok {
+			var name, _ = mindustry.ContentAt(typ, id)
+			return "@" + name
+		}
+	case *parser.Ident:
+		return this.variable(expr.Name)
+	case *parser.Builtin:
+		return expr.Name
+	case *parser.Constant:
+		return expr.Value
+	case *parser.Unary:
+		if value, ok := signedNumber(expr); ok {
+			return value
+		}
 
-	return outLine
+		if expr.Op == "+" {
+			return this.constructOperation(expr.Operand)
+		}
+	}
+
+	var tmp = this.temp()
+	this.constructInto(tmp, expr)
+	return tmp
 }
 
-// Construct a variable assignment line
-//
-// Pass in the parts of the line which has been split by spaces
-func Assignment(parts []string) string {
-	if utils.ContainsListItem(parts, operatorSymbols) {
-		return strings.Join(constructOperation(parts), " ")
-	} else {
-		return strings.Join(constructVariable(parts), " ")
+// Construct the instructions that store the result of the expression into dest
+func (this *constructor) constructInto(dest string, expr parser.Expr) {
+	switch expr := expr.(type) {
+	case *parser.Binary:
+		var left = this.constructOperation(expr.Left)
+		var right = this.constructOperation(expr.Right)
+
+		if expr.Op == "||" {
+			//? op or is a bitwise or that drops fractions, compare each side against 0 first like land does
+			var leftTruth, rightTruth = this.temp(), this.temp()
+			this.emit("op", "notEqual", leftTruth, left, "0")
+			this.emit("op", "notEqual", rightTruth, right, "0")
+			this.emit("op", "or", dest, leftTruth, rightTruth)
+			return
+		}
+
+		this.emit("op", getOperator(expr.Op), dest, left, right)
+
+	case *parser.Unary:
+		switch expr.Op {
+		case "!":
+			//? op not is a bitwise not, compare against false to get a logical not
+			this.emit("op", "equal", dest, this.constructOperation(expr.Operand), "false")
+		case "-":
+			if value, ok := signedNumber(expr); ok {
+				this.constructVariable(dest, value)
+			} else {
+				this.emit("op", "sub", dest, "0", this.constructOperation(expr.Operand))
+			}
+		default:
+			this.constructVariable(dest, this.constructOperation(expr))
+		}
+
+	case *parser.Member:
+		this.sensor(dest, expr)
+
+	case *parser.Conditional:
+		this.conditional(dest, expr)
+
+	case *parser.Call:
+		this.call(expr, dest)
+
+	default:
+		this.constructVariable(dest, this.constructOperation(expr))
 	}
 }
+
+// Construct a variable assignment
+func (this *constructor) assignment(name string, value parser.Expr) {
+	this.constructInto(name, value)
+}
@@ -0,0 +1,19 @@
+package constructor
+
+import (
+	"conveycode/compiler/mindustry"
+	"strconv"
+)
+
+// Returns the mlog literal for the hexadecimal digits of a color literal,
+// a color without alpha channel is opaque
+//
+//	#ff8800 // %ff8800ff
+func (this *constructor) color(hex string) string {
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+
+	var rgba, _ = strconv.ParseUint(hex, 16, 32)
+	return mindustry.ColorLiteral(uint32(rgba), this.target.GameVersion())
+}
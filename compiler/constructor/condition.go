@@ -0,0 +1,67 @@
+package constructor
+
+import "conveycode/compiler/parser"
+
+// The jump condition that is true exactly when the key condition is false
+var inverseConditions = map[string]string{
+	"equal":         "notEqual",
+	"notEqual":      "equal",
+	"lessThan":      "greaterThanEq",
+	"lessThanEq":    "greaterThan",
+	"greaterThan":   "lessThanEq",
+	"greaterThanEq": "lessThan",
+}
+
+// Construct an if statement
+//
+//	jump else <inverted condition>
+//	<then>
+//	jump end always
+//	else:
+//	<else>
+//	end:
+func (this *constructor) condition(stmt *parser.If) {
+	var elseLabel = this.label()
+
+	this.jumpUnless(stmt.Cond, elseLabel)
+
+	var before = this.draws
+	this.statement(stmt.Then)
+	var then = this.draws
+	this.draws = before
+
+	defer func() {
+		this.draws = mergeDraws(then, this.draws)
+	}()
+
+	if stmt.Else == nil {
+		this.place(elseLabel)
+		return
+	}
+
+	var endLabel = this.label()
+	this.emit("jump", endLabel, "always")
+	this.place(elseLabel)
+	this.statement(stmt.Else)
+	this.place(endLabel)
+}
+
+// Construct a jump to the label that is taken when the condition is false
+func (this *constructor) jumpUnless(cond parser.Expr, label string) {
+	if binary, ok := cond.(*parser.Binary); ok {
+		if binary.Op == "&&" {
+			this.jumpUnless(binary.Left, label)
+			this.jumpUnless(binary.Right, label)
+			return
+		}
+
+		if inverse, ok := inverseConditions[getOperator(binary.Op)]; ok {
+			var left = this.constructOperation(binary.Left)
+			var right = this.constructOperation(binary.Right)
+			this.emit("jump", label, inverse, left, right)
+			return
+		}
+	}
+
+	this.emit("jump", label, "equal", this.constructOperation(cond), "false")
+}
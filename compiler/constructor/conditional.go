@@ -0,0 +1,63 @@
+package constructor
+
+import (
+	"conveycode/compiler/mindustry"
+	"conveycode/compiler/parser"
+)
+
+// Construct a conditional expression into dest.
+// v8 picks the value with select, which calculates both values,
+// so it is only used when calculating them has no effects
+//
+//	select dest lessThan hp 50 "low" "ok"
+//
+// Older versions, and values that call functions, jump over the value that is not picked
+//
+//	jump else greaterThanEq hp 50
+//	set dest "low"
+//	jump end always
+//	else:
+//	set dest "ok"
+//	end:
+func (this *constructor) conditional(dest string, expr *parser.Conditional) {
+	if this.target.Supports(mindustry.V8) && !containsCalls(expr.Then) && !containsCalls(expr.Else) {
+		var condition, left, right = this.selectCondition(expr.Cond)
+		this.emit("select", dest, condition, left, right, this.constructOperation(expr.Then), this.constructOperation(expr.Else))
+		return
+	}
+
+	var elseLabel = this.label()
+	var endLabel = this.label()
+
+	this.jumpUnless(expr.Cond, elseLabel)
+	this.constructInto(dest, expr.Then)
+	this.emit("jump", endLabel, "always")
+	this.place(elseLabel)
+	this.constructInto(dest, expr.Else)
+	this.place(endLabel)
+}
+
+// Returns the condition and operands of select for the expression,
+// a comparison is used directly and any other value is compared against false
+func (this *constructor) selectCondition(cond parser.Expr) (condition string, left string, right string) {
+	if binary, ok := cond.(*parser.Binary); ok {
+		var operator = getOperator(binary.Op)
+
+		if _, ok := inverseConditions[operator]; ok || operator == "strictEqual" {
+			return operator, this.constructOperation(binary.Left), this.constructOperation(binary.Right)
+		}
+	}
+
+	return "notEqual", this.constructOperation(cond), "false"
+}
+
+func containsCalls(expr parser.Expr) (found bool) {
+	parser.Inspect(expr, func(node parser.Node) bool {
+		if _, ok := node.(*parser.Call); ok {
+			found = true
+		}
+		return !found
+	})
+
+	return found
+}
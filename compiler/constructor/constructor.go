@@ -0,0 +1,279 @@
+package constructor
+
+import (
+	"conveycode/compiler/builtins"
+	"conveycode/compiler/diagnostics"
+	"conveycode/compiler/ir"
+	"conveycode/compiler/mindustry"
+	"conveycode/compiler/parser"
+	"fmt"
+)
+
+type constructor struct {
+	code  []ir.Instruction
+	diags *diagnostics.List
+
+	// The statement and function that are being constructed, instructions are tagged with it
+	origin ir.Origin
+
+	// Newer versions of the game have instructions that some constructs can be lowered to instead
+	target mindustry.Target
+
+	// Counters used to generate unique temporary variable and label names
+	temps  int
+	labels int
+
+	// The building each link name in the code refers to
+	links map[string]string
+
+	// The loops the current statement is in, the innermost loop is last
+	loops []loop
+
+	// The most draw commands that can be buffered when the current instruction runs, or unknownDraws
+	draws int
+
+	// The display drawflush is inserted for when the draw buffer fills up
+	display *parser.Ident
+}
+
+// Construct the instructions for the program, warnings are added to diags
+//
+// The program is expected to have passed the checker
+func Construct(program *parser.Program, target mindustry.Target, diags *diagnostics.List) *ir.Program {
+	var this = &constructor{
+		diags:   diags,
+		target:  target,
+		links:   map[string]string{},
+		display: flushDisplay(program),
+	}
+	this.draws = this.programDraws(program)
+
+	for _, stmt := range program.Stmts {
+		this.statement(stmt)
+	}
+
+	return ir.Build(this.code)
+}
+
+func (this *constructor) statement(stmt parser.Stmt) {
+	var outer = this.origin
+	this.origin.Pos = stmt.Position()
+	this.origin.Construct = constructName(stmt)
+	defer func() { this.origin = outer }()
+
+	switch stmt := stmt.(type) {
+	case *parser.VarDecl:
+		if len(stmt.Names) > 1 {
+			this.call(stmt.Value.(*parser.Call), stmt.Names...)
+		} else {
+			this.assignment(stmt.Names[0], stmt.Value)
+		}
+	case *parser.Assign:
+		if member, ok := stmt.Target.(*parser.Member); ok {
+			this.control(member, stmt.Value)
+		} else {
+			this.assignment(this.constructOperation(stmt.Target), stmt.Value)
+		}
+	case *parser.Link:
+		for i, name := range stmt.Names {
+			this.links[name] = stmt.Buildings[i]
+		}
+	case *parser.If:
+		this.condition(stmt)
+	case *parser.ForIn:
+		this.forLinks(stmt)
+	case *parser.Switch:
+		this.switchStatement(stmt)
+	case *parser.Asm:
+		this.asm(stmt)
+	case *parser.Break:
+		this.emit("jump", this.loops[len(this.loops)-1].breakLabel, "always")
+	case *parser.Continue:
+		this.emit("jump", this.loops[len(this.loops)-1].continueLabel, "always")
+	case *parser.Block:
+		for _, inner := range stmt.Stmts {
+			this.statement(inner)
+		}
+	case *parser.ExprStmt:
+		this.call(stmt.Expr.(*parser.Call))
+	}
+}
+
+// Returns the name the size report uses for the kind of statement
+func constructName(stmt parser.Stmt) string {
+	switch stmt.(type) {
+	case *parser.VarDecl:
+		return "var"
+	case *parser.Assign:
+		return "assignment"
+	case *parser.If:
+		return "if"
+	case *parser.ForIn:
+		return "for"
+	case *parser.Switch:
+		return "switch"
+	case *parser.Asm:
+		return "asm"
+	case *parser.Break:
+		return "break"
+	case *parser.Continue:
+		return "continue"
+	case *parser.Block:
+		return "block"
+	case *parser.Link:
+		return "use"
+	}
+
+	return "call"
+}
+
+// Construct a call to a built-in function, the results are written to the given variables
+//
+// Results that are not given are written to temporary variables
+func (this *constructor) call(call *parser.Call, results ...string) {
+	var name, function, _ = builtins.Lookup(call)
+
+	var outer = this.origin.Function
+	this.origin.Function = name
+	defer func() { this.origin.Function = outer }()
+
+	switch name {
+	case "print":
+		this.printer(call.Args, false)
+	case "println":
+		this.printer(call.Args, true)
+	case "draw.flush":
+		this.instruction(function, call, results)
+		this.draws = 0
+	case "rgba":
+		if rgba, ok := builtins.ConstantColor(call); ok {
+			if len(results) > 0 && results[0] != "_" {
+				this.constructVariable(results[0], mindustry.ColorLiteral(rgba, this.target.GameVersion()))
+			}
+			return
+		}
+
+		this.instruction(function, call, results)
+	case "sleep":
+		this.sleep(call)
+	case "item", "liquid", "unit", "block":
+		if typ, id, ok := builtins.ConstantContent(call); ok {
+			if len(results) > 0 && results[0] != "_" {
+				var content, _ = mindustry.ContentAt(typ, id)
+				this.constructVariable(results[0], "@"+content)
+			}
+			return
+		}
+
+		this.instruction(function, call, results)
+	default:
+		if function.Instruction == "draw" {
+			this.draw(call, function)
+			return
+		}
+
+		this.instruction(function, call, results)
+	}
+}
+
+// Construct the instruction of the function, padding the unused operands with 0
+//
+//	unit.move(x, y) // ucontrol move x y 0 0 0
+func (this *constructor) instruction(function builtins.Function, call *parser.Call, results []string) {
+	var bound, _ = function.Bind(call)
+	var operands = map[string]string{}
+
+	for i, param := range function.Params {
+		switch {
+		case bound[i] == nil:
+			operands[param.Name] = param.Default
+		case param.Enum != nil:
+			operands[param.Name] = param.Enum[bound[i].(*parser.Ident).Name]
+		default:
+			operands[param.Name] = this.constructOperation(bound[i])
+		}
+	}
+
+	//? Results that are not used, or thrown away with _, still need a variable to be written to
+	for i, result := range function.Results {
+		if i < len(results) && results[i] != "_" {
+			operands[result] = results[i]
+		} else {
+			operands[result] = this.temp()
+		}
+	}
+
+	if function.Adjust != nil {
+		function.Adjust(operands)
+	}
+
+	var layout = function.Layout
+	if layout == nil {
+		for _, param := range function.Params {
+			layout = append(layout, param.Name)
+		}
+		layout = append(layout, function.Results...)
+	}
+
+	var parts []string
+	for _, name := range layout {
+		if operand, ok := operands[name]; ok {
+			parts = append(parts, operand)
+		} else {
+			parts = append(parts, name)
+		}
+	}
+
+	this.emitInstruction(function.Definition(), parts...)
+}
+
+// Returns the mlog variable name for a name used in the code
+func (this *constructor) variable(name string) string {
+	if building, ok := this.links[name]; ok {
+		return building
+	}
+
+	return name
+}
+
+//#region Emitting
+
+func (this *constructor) emit(parts ...string) {
+	this.code = append(this.code, ir.New(this.origin, parts...))
+}
+
+// Emit the instruction of the definition, the operands the game reads after the given ones are filled with 0
+//
+//	emitInstruction(control enabled, "switch1", "false") // control enabled switch1 false 0 0 0
+func (this *constructor) emitInstruction(definition mindustry.Instruction, operands ...string) {
+	var parts = []string{definition.Opcode}
+	if definition.Mode != "" {
+		parts = append(parts, definition.Mode)
+	}
+
+	parts = append(parts, operands...)
+	for range definition.Operands() - len(operands) {
+		parts = append(parts, "0")
+	}
+
+	this.emit(parts...)
+}
+
+// Returns a new unique temporary variable name
+func (this *constructor) temp() string {
+	this.temps++
+	return fmt.Sprintf("%s%d", ir.TempPrefix, this.temps-1)
+}
+
+// Returns a new unique label name, place it with constructor.place
+func (this *constructor) label() string {
+	this.labels++
+	return fmt.Sprintf("__label%d", this.labels-1)
+}
+
+// Marks the position of the next instruction with the label
+func (this *constructor) place(label string) {
+	this.code = append(this.code, ir.Mark(label))
+}
+
+//#endregion
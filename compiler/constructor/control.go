@@ -0,0 +1,18 @@
+package constructor
+
+import (
+	"conveycode/compiler/mindustry"
+	"conveycode/compiler/parser"
+)
+
+// Construct a control instruction that sets the property of the building
+//
+//	building.enabled = false // control enabled building false 0 0 0
+//	sorter.config = @copper  // control config sorter @copper 0 0 0
+func (this *constructor) control(member *parser.Member, value parser.Expr) {
+	var building = this.constructOperation(member.Object)
+	var operand = this.constructOperation(value)
+
+	var definition, _ = mindustry.ControlOf(member.Property)
+	this.emitInstruction(definition, building, operand)
+}
@@ -0,0 +1,229 @@
+package constructor
+
+import (
+	"conveycode/compiler/builtins"
+	"conveycode/compiler/mindustry"
+	"conveycode/compiler/parser"
+)
+
+// The number of buffered draw commands when it can not be known at compile time
+const unknownDraws = -1
+
+// Construct a draw command, flushing the buffer first when it would overflow
+func (this *constructor) draw(call *parser.Call, function builtins.Function) {
+	if this.draws >= mindustry.GraphicsBuffer {
+		if this.display == nil {
+			this.diags.Warnf(call.Pos, "more than %d draw commands are buffered here, call draw.flush to keep them from being dropped", mindustry.GraphicsBuffer)
+			this.draws = unknownDraws
+		} else {
+			this.emit("drawflush", this.constructOperation(this.display))
+			this.draws = 0
+		}
+	}
+
+	this.instruction(function, call, nil)
+
+	if this.draws != unknownDraws {
+		this.draws++
+	}
+}
+
+// Returns the display every draw.flush in the program goes to,
+// nil if there is none or if there are several
+func flushDisplay(program *parser.Program) (display *parser.Ident) {
+	var several = false
+
+	parser.Inspect(program, func(node parser.Node) bool {
+		var call, ok = node.(*parser.Call)
+		if !ok {
+			return true
+		}
+
+		var name, function, _ = builtins.Lookup(call)
+		if name != "draw.flush" {
+			return true
+		}
+
+		var bound, err = function.Bind(call)
+		if err != nil {
+			return true
+		}
+
+		var ident, isIdent = bound[0].(*parser.Ident)
+		if !isIdent || (display != nil && display.Name != ident.Name) {
+			several = true
+		}
+
+		display = ident
+		return true
+	})
+
+	if several {
+		return nil
+	}
+
+	return display
+}
+
+// The draw commands buffered after both paths of a branch came together
+func mergeDraws(a int, b int) int {
+	if a == unknownDraws || b == unknownDraws {
+		return unknownDraws
+	}
+
+	return max(a, b)
+}
+
+// Returns the number of draw commands buffered at the start of each iteration of the loop,
+// warning when it can not be known
+func (this *constructor) loopDraws(loop *parser.ForIn) int {
+	if this.draws == unknownDraws || !containsDraws(loop.Body) {
+		return this.draws
+	}
+
+	var carried = unknownDraws
+	if !containsContinue(loop.Body) {
+		carried = carriedDraws(loop.Body.Stmts)
+	}
+
+	if carried == unknownDraws {
+		this.diags.Warnf(loop.Pos, "can not tell how many draw commands are buffered in this loop, call draw.flush in the loop to keep the buffer from overflowing")
+		return unknownDraws
+	}
+
+	return max(this.draws, carried)
+}
+
+// Returns the number of draw commands buffered when the program starts,
+// which are the ones left over from the previous run since the program starts over after its last instruction
+func (this *constructor) programDraws(program *parser.Program) int {
+	if !containsDraws(program) {
+		return 0
+	}
+
+	var carried = unknownDraws
+	if !containsCall(program, "end") {
+		carried = carriedDraws(program.Stmts)
+	}
+
+	if carried == unknownDraws {
+		this.diags.Warnf(program.Position(), "can not tell how many draw commands are still buffered when the program starts over, call draw.flush at the end of the program to keep the buffer from overflowing")
+	}
+
+	return carried
+}
+
+// Returns the number of draw commands that are still buffered when the statements run again after they ended,
+// or unknownDraws when it can not be known
+func carriedDraws(stmts []parser.Stmt) int {
+	//? Only the commands after the last flush carry over to the next run
+	var last = -1
+	for i, stmt := range stmts {
+		if isCallTo(stmt, "draw.flush") {
+			last = i
+		}
+	}
+
+	var carried = simulateDraws(stmts[last+1:], 0)
+
+	//? Without a flush the commands pile up with every run, unless every path flushes on its own
+	if last < 0 && carried != 0 {
+		return unknownDraws
+	}
+
+	return carried
+}
+
+// Returns the number of draw commands buffered after the statements,
+// following the same rules as the constructor
+func simulateDraws(stmts []parser.Stmt, draws int) int {
+	for _, stmt := range stmts {
+		if draws == unknownDraws {
+			return draws
+		}
+
+		switch stmt := stmt.(type) {
+		case *parser.ExprStmt:
+			switch {
+			case isCallTo(stmt, "draw.flush"):
+				draws = 0
+			case isDraw(stmt.Expr):
+				if draws >= mindustry.GraphicsBuffer {
+					draws = 0
+				}
+				draws++
+			}
+		case *parser.If:
+			var then = simulateDraws(stmt.Then.Stmts, draws)
+			var otherwise = simulateDraws([]parser.Stmt{stmt.Else}, draws)
+			draws = mergeDraws(then, otherwise)
+		case *parser.Block:
+			draws = simulateDraws(stmt.Stmts, draws)
+		default:
+			//? Loops and switches can draw any number of times
+			if containsDraws(stmt) {
+				draws = unknownDraws
+			}
+		}
+	}
+
+	return draws
+}
+
+//#region Utilities
+
+func isDraw(expr parser.Expr) bool {
+	if call, ok := expr.(*parser.Call); ok {
+		var _, function, _ = builtins.Lookup(call)
+		return function.Instruction == "draw"
+	}
+
+	return false
+}
+
+func isCallTo(stmt parser.Stmt, name string) bool {
+	if stmt, ok := stmt.(*parser.ExprStmt); ok {
+		if call, ok := stmt.Expr.(*parser.Call); ok {
+			var called, _ = builtins.Name(call.Callee)
+			return called == name
+		}
+	}
+
+	return false
+}
+
+func containsDraws(node parser.Node) (found bool) {
+	parser.Inspect(node, func(node parser.Node) bool {
+		if expr, ok := node.(parser.Expr); ok && isDraw(expr) {
+			found = true
+		}
+		return !found
+	})
+
+	return found
+}
+
+func containsCall(node parser.Node, name string) (found bool) {
+	parser.Inspect(node, func(node parser.Node) bool {
+		if call, ok := node.(*parser.Call); ok {
+			var called, _ = builtins.Name(call.Callee)
+			found = called == name
+		}
+		return !found
+	})
+
+	return found
+}
+
+func containsContinue(node parser.Node) (found bool) {
+	parser.Inspect(node, func(node parser.Node) bool {
+		if _, ok := node.(*parser.Continue); ok {
+			found = true
+		}
+		return !found
+	})
+
+	return found
+}
+
+//#endregion
@@ -0,0 +1,44 @@
+package constructor
+
+import "conveycode/compiler/parser"
+
+// The labels that break and continue jump to
+type loop struct {
+	breakLabel    string
+	continueLabel string
+}
+
+// Construct a loop over every building linked to the processor
+//
+//	set i 0
+//	start:
+//	jump end greaterThanEq i @links
+//	getlink building i
+//	op add i i 1
+//	<body>
+//	jump start always
+//	end:
+func (this *constructor) forLinks(stmt *parser.ForIn) {
+	var index = this.temp()
+	var current = loop{breakLabel: this.label(), continueLabel: this.label()}
+
+	this.constructVariable(index, "0")
+	this.place(current.continueLabel)
+	this.emit("jump", current.breakLabel, "greaterThanEq", index, "@links")
+	this.emit("getlink", this.variable(stmt.Name), index)
+
+	//? Incrementing before the body lets continue jump straight back to the start
+	this.emit("op", "add", index, index, "1")
+
+	this.draws = this.loopDraws(stmt)
+	var head = this.draws
+
+	this.loops = append(this.loops, current)
+	this.statement(stmt.Body)
+	this.loops = this.loops[:len(this.loops)-1]
+
+	this.draws = mergeDraws(head, this.draws)
+
+	this.emit("jump", current.continueLabel, "always")
+	this.place(current.breakLabel)
+}
@@ -1,5 +1,15 @@
 package constructor
 
-func Printer(parts []string) {
+import "conveycode/compiler/parser"
 
+// Construct a print instruction for each argument,
+// when newline is set a line break is printed after the arguments
+func (this *constructor) printer(args []parser.Expr, newline bool) {
+	for _, arg := range args {
+		this.emit("print", this.constructOperation(arg))
+	}
+
+	if newline {
+		this.emit("print", "\"\\n\"")
+	}
 }
@@ -0,0 +1,15 @@
+package constructor
+
+import (
+	"conveycode/compiler/parser"
+	"strings"
+)
+
+// Construct a sensor instruction that reads the property of the object into dest
+//
+//	container1.@copper // sensor dest container1 @copper
+//	turret.ammo        // sensor dest turret @ammo
+func (this *constructor) sensor(dest string, member *parser.Member) {
+	var object = this.constructOperation(member.Object)
+	this.emit("sensor", dest, object, "@"+strings.TrimPrefix(member.Property, "@"))
+}
@@ -0,0 +1,162 @@
+package constructor
+
+import (
+	"conveycode/compiler/ir"
+	"conveycode/compiler/parser"
+	"strconv"
+)
+
+// The fewest case values that are worth a jump table, a few comparisons are just as fast
+const minTableCases = 3
+
+// Construct a switch statement, with a jump table when the cases are dense integers
+// and with a chain of comparisons otherwise.
+// Each case ends with a jump past the other cases, break jumps there as well
+func (this *constructor) switchStatement(stmt *parser.Switch) {
+	var value = this.constructOperation(stmt.Value)
+	var end = this.label()
+
+	var labels = make([]string, len(stmt.Cases))
+	for i := range labels {
+		labels[i] = this.label()
+	}
+
+	var fallback = end
+	if stmt.Default != nil {
+		fallback = this.label()
+	}
+
+	if low, high, ok := denseCases(stmt); ok {
+		this.jumpTable(stmt, value, labels, fallback, low, high)
+	} else {
+		this.comparisons(stmt, value, labels, fallback)
+	}
+
+	//? continue inside a switch still goes to the loop around it
+	var current = loop{breakLabel: end}
+	if len(this.loops) > 0 {
+		current.continueLabel = this.loops[len(this.loops)-1].continueLabel
+	}
+	this.loops = append(this.loops, current)
+
+	var before = this.draws
+	var after = before
+	if stmt.Default != nil {
+		after = 0
+	}
+
+	for i, c := range stmt.Cases {
+		this.place(labels[i])
+		this.draws = before
+		this.statement(c.Body)
+		after = mergeDraws(after, this.draws)
+
+		if i < len(stmt.Cases)-1 || stmt.Default != nil {
+			this.emit("jump", end, "always")
+		}
+	}
+
+	if stmt.Default != nil {
+		this.place(fallback)
+		this.draws = before
+		this.statement(stmt.Default)
+		after = mergeDraws(after, this.draws)
+	}
+
+	this.loops = this.loops[:len(this.loops)-1]
+	this.draws = after
+	this.place(end)
+}
+
+// Jump to the case by adding the value to @counter, which skips to the slot of the value.
+// Values that have no case go to the default, as do values with a fraction,
+// which would otherwise land between two slots
+//
+//	op floor index value
+//	jump default notEqual index value
+//	jump default lessThan index low
+//	jump default greaterThan index high
+//	op sub index index low
+//	op add @counter @counter index
+//	jump case0 always
+//	jump case1 always
+func (this *constructor) jumpTable(stmt *parser.Switch, value string, labels []string, fallback string, low int, high int) {
+	var slots = make([]string, high-low+1)
+	for i := range slots {
+		slots[i] = fallback
+	}
+
+	for i, c := range stmt.Cases {
+		for _, caseValue := range c.Values {
+			var number, _ = integerCase(caseValue)
+			slots[number-low] = labels[i]
+		}
+	}
+
+	//? null and other objects floor to 0 or 1 and compare equal to that, just like the cases of a comparison chain
+	var index = this.temp()
+	this.emit("op", "floor", index, value, "0")
+	this.emit("jump", fallback, "notEqual", index, value)
+	this.emit("jump", fallback, "lessThan", index, strconv.Itoa(low))
+	this.emit("jump", fallback, "greaterThan", index, strconv.Itoa(high))
+
+	if low != 0 {
+		this.emit("op", "sub", index, index, strconv.Itoa(low))
+	}
+
+	//? The table is one instruction so that no pass can separate the slots from the addition
+	this.code = append(this.code, ir.Table(this.origin, index, slots))
+}
+
+// Jump to the first case that has a value equal to the value, or to the default
+//
+//	jump case0 equal value 0
+//	jump case1 strictEqual value @router
+//	jump default always
+func (this *constructor) comparisons(stmt *parser.Switch, value string, labels []string, fallback string) {
+	for i, c := range stmt.Cases {
+		for _, caseValue := range c.Values {
+			//? equal treats every object as 1, so only numbers can be compared loosely
+			var condition = "strictEqual"
+			if _, ok := signedNumber(caseValue); ok {
+				condition = "equal"
+			}
+
+			this.emit("jump", labels[i], condition, value, this.constructOperation(caseValue))
+		}
+	}
+
+	this.emit("jump", fallback, "always")
+}
+
+// Returns the range of the case values when they are all integers and fill at least half of the range
+func denseCases(stmt *parser.Switch) (low int, high int, ok bool) {
+	var count = 0
+
+	for _, c := range stmt.Cases {
+		for _, value := range c.Values {
+			var number, isInteger = integerCase(value)
+			if !isInteger {
+				return 0, 0, false
+			}
+
+			if count == 0 {
+				low, high = number, number
+			}
+			low, high = min(low, number), max(high, number)
+			count++
+		}
+	}
+
+	return low, high, count >= minTableCases && high-low+1 <= count*2
+}
+
+func integerCase(value parser.Expr) (int, bool) {
+	var literal, ok = signedNumber(value)
+	if !ok {
+		return 0, false
+	}
+
+	var number, err = strconv.ParseInt(literal, 0, 32)
+	return int(number), err == nil
+}
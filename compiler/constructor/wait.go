@@ -0,0 +1,25 @@
+package constructor
+
+import (
+	"conveycode/compiler/builtins"
+	"conveycode/compiler/parser"
+	"strconv"
+)
+
+// Construct a wait for the given number of milliseconds, a number literal is converted to seconds by the compiler
+//
+//	sleep(250) // wait 0.25
+func (this *constructor) sleep(call *parser.Call) {
+	var bound, _ = builtins.Functions["sleep"].Bind(call)
+
+	if value, ok := signedNumber(bound[0]); ok {
+		if milliseconds, err := strconv.ParseFloat(value, 64); err == nil {
+			this.emit("wait", strconv.FormatFloat(milliseconds/1000, 'f', -1, 64))
+			return
+		}
+	}
+
+	var seconds = this.temp()
+	this.emit("op", "div", seconds, this.constructOperation(bound[0]), "1000")
+	this.emit("wait", seconds)
+}
@@ -0,0 +1,61 @@
+package diagnostics
+
+import (
+	"conveycode/compiler/types"
+	"fmt"
+
+	"github.com/TwiN/go-color"
+)
+
+type Severity int
+
+const (
+	_ Severity = iota
+
+	Warning
+	Error
+)
+
+func (this Severity) String() string {
+	return [...]string{
+		"Warning",
+		"Error",
+	}[this-1]
+}
+
+// A message about the source that is reported back to the user
+type Diagnostic struct {
+	Severity Severity
+	Pos      types.Position
+	Message  string
+}
+
+func (this Diagnostic) String() string {
+	var col = color.Yellow
+	if this.Severity == Error {
+		col = color.Red
+	}
+
+	return fmt.Sprintf("%s at %s: %s", color.Colorize(col, this.Severity.String()), color.InYellow(this.Pos), this.Message)
+}
+
+type List []Diagnostic
+
+func (this *List) Errorf(pos types.Position, format string, args ...any) {
+	*this = append(*this, Diagnostic{Severity: Error, Pos: pos, Message: fmt.Sprintf(format, args...)})
+}
+
+func (this *List) Warnf(pos types.Position, format string, args ...any) {
+	*this = append(*this, Diagnostic{Severity: Warning, Pos: pos, Message: fmt.Sprintf(format, args...)})
+}
+
+// Wether any of the diagnostics is an error
+func (this List) HasErrors() bool {
+	for _, diag := range this {
+		if diag.Severity == Error {
+			return true
+		}
+	}
+
+	return false
+}
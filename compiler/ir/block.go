@@ -0,0 +1,148 @@
+package ir
+
+// A run of instructions that is only entered at the start and only left at the end
+type Block struct {
+	// The labels that point to the start of the block
+	Labels []string
+
+	Instructions []Instruction
+
+	// The blocks that can run directly after or before this block
+	Succs []*Block
+	Preds []*Block
+}
+
+// Returns the last instruction of the block, false when the block is empty
+func (this *Block) Last() (Instruction, bool) {
+	if len(this.Instructions) == 0 {
+		return Instruction{}, false
+	}
+
+	return this.Instructions[len(this.Instructions)-1], true
+}
+
+// The basic blocks of a program in the order they are emitted, linked into a control-flow graph
+type Program struct {
+	Blocks []*Block
+}
+
+// Splits the instructions into basic blocks and links them
+//
+// A new block starts at every label and after every instruction that jumps or ends the program
+func Build(code []Instruction) *Program {
+	var program = &Program{}
+	var current = &Block{}
+
+	var split = func() {
+		program.Blocks = append(program.Blocks, current)
+		current = &Block{}
+	}
+
+	for _, instruction := range code {
+		if instruction.Op == LabelOp {
+			if len(current.Instructions) > 0 {
+				split()
+			}
+			current.Labels = append(current.Labels, instruction.Args[0].Name)
+			continue
+		}
+
+		current.Instructions = append(current.Instructions, instruction)
+		if instruction.Ends() || instruction.IsConditional() {
+			split()
+		}
+	}
+
+	if len(current.Instructions) > 0 || len(current.Labels) > 0 {
+		split()
+	}
+
+	program.Link()
+	return program
+}
+
+// Returns the instructions of the program in order, with a marker for every label
+func (this *Program) Code() (code []Instruction) {
+	for _, block := range this.Blocks {
+		for _, label := range block.Labels {
+			code = append(code, Mark(label))
+		}
+		code = append(code, block.Instructions...)
+	}
+
+	return code
+}
+
+// Returns the number of mlog instructions the program is emitted as
+func (this *Program) Size() (size int) {
+	for _, block := range this.Blocks {
+		for _, instruction := range block.Instructions {
+			size += instruction.Size()
+		}
+	}
+
+	return size
+}
+
+// Returns the block each label points to
+func (this *Program) Labels() map[string]*Block {
+	var labels = map[string]*Block{}
+	for _, block := range this.Blocks {
+		for _, label := range block.Labels {
+			labels[label] = block
+		}
+	}
+
+	return labels
+}
+
+// Recomputes the successors and predecessors of every block, call it after changing the blocks
+//
+// The processor starts over at the first block after end and after the last instruction,
+// and it halts after stop. A write to @counter can go anywhere
+func (this *Program) Link() {
+	var labels = this.Labels()
+
+	for _, block := range this.Blocks {
+		block.Succs, block.Preds = nil, nil
+	}
+
+	for i, block := range this.Blocks {
+		var next = this.Blocks[0]
+		if i+1 < len(this.Blocks) {
+			next = this.Blocks[i+1]
+		}
+
+		var last, ok = block.Last()
+		switch {
+		case !ok:
+			block.Succs = []*Block{next}
+		case last.Op == "stop":
+		case last.Op == "end":
+			block.Succs = []*Block{this.Blocks[0]}
+		case last.WritesCounter():
+			block.Succs = this.Blocks
+		default:
+			for _, label := range last.Targets() {
+				block.Succs = appendBlock(block.Succs, labels[label])
+			}
+			if !last.Ends() {
+				block.Succs = appendBlock(block.Succs, next)
+			}
+		}
+
+		for _, succ := range block.Succs {
+			succ.Preds = appendBlock(succ.Preds, block)
+		}
+	}
+}
+
+func appendBlock(blocks []*Block, block *Block) []*Block {
+	for _, existing := range blocks {
+		if existing == block {
+			return blocks
+		}
+	}
+
+	return append(blocks, block)
+}
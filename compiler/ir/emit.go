@@ -0,0 +1,63 @@
+package ir
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Returns the mlog lines of the program, with the labels replaced by instruction indices
+//
+// A label at the end of the program points to 0, since the processor wraps around after the last instruction
+func Emit(program *Program) []string {
+	var indices = map[string]int{}
+	var size = 0
+
+	for _, block := range program.Blocks {
+		for _, label := range block.Labels {
+			indices[label] = size
+		}
+		for _, instruction := range block.Instructions {
+			size += instruction.Size()
+		}
+	}
+
+	var resolve = func(label string) string {
+		if indices[label] == size {
+			return "0"
+		}
+		return fmt.Sprint(indices[label])
+	}
+
+	var lines []string
+	for _, block := range program.Blocks {
+		for _, instruction := range block.Instructions {
+			lines = append(lines, emitInstruction(instruction, resolve)...)
+		}
+	}
+
+	return lines
+}
+
+// Returns the mlog lines of one instruction, a jump table is emitted as several lines
+//
+//	op add @counter @counter index
+//	jump 12 always
+func emitInstruction(instruction Instruction, resolve func(string) string) []string {
+	if instruction.Op == TableOp {
+		//? @counter already points to the next instruction, which is the first slot
+		var lines = []string{"op add @counter @counter " + instruction.Args[0].Name}
+		for _, label := range instruction.Targets() {
+			lines = append(lines, "jump "+resolve(label)+" always")
+		}
+		return lines
+	}
+
+	var parts = instruction.Parts()
+	for i, arg := range instruction.Args {
+		if arg.Kind == Label {
+			parts[len(parts)-len(instruction.Args)+i] = resolve(arg.Name)
+		}
+	}
+
+	return []string{strings.Join(parts, " ")}
+}
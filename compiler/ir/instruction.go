@@ -0,0 +1,209 @@
+package ir
+
+import (
+	"conveycode/compiler/mindustry"
+	"conveycode/compiler/types"
+	"slices"
+	"strings"
+)
+
+const (
+	// Marks the position of the next instruction with the label in Args[0], it is not an instruction itself
+	LabelOp = "label"
+
+	// Jumps to the label in Args[1+index] for the index in Args[0], it is emitted as
+	// an addition to @counter followed by one jump per label
+	TableOp = "table"
+)
+
+// A single instruction on variables, temporaries and symbolic labels
+type Instruction struct {
+	Op string
+
+	// The sub mode, empty for instructions that do not have one
+	Mode string
+
+	// The operands after the mode
+	Args []Value
+
+	// The part of the source the instruction was constructed for
+	Origin Origin
+}
+
+// Where in the source an instruction comes from
+type Origin struct {
+	// The position of the statement
+	Pos types.Position
+
+	// The kind of statement, such as if or switch
+	Construct string
+
+	// The built-in function the instruction calls, empty when it is not part of a call
+	Function string
+}
+
+// Returns the instruction for the mlog parts, the operands are classified by the instruction table
+//
+//	New(origin, "op", "add", "x", "x", "1")
+func New(origin Origin, parts ...string) Instruction {
+	var instruction = Instruction{Op: parts[0], Origin: origin}
+	var operands = parts[1:]
+
+	if mindustry.HasModes(instruction.Op) && len(operands) > 0 {
+		instruction.Mode, operands = operands[0], operands[1:]
+	}
+
+	var definition, _ = mindustry.FindInstruction(instruction.Op, instruction.Mode)
+	for i, kind := range argKinds(definition, len(operands)) {
+		var value = Classify(operands[i])
+		switch kind {
+		case mindustry.Word:
+			value.Kind = Word
+		case mindustry.Label:
+			value.Kind = Label
+		}
+
+		instruction.Args = append(instruction.Args, value)
+	}
+
+	return instruction
+}
+
+// Returns the marker that places the label before the next instruction
+func Mark(label string) Instruction {
+	return Instruction{Op: LabelOp, Args: []Value{{Kind: Label, Name: label}}}
+}
+
+// Returns a jump table that jumps to labels[index]
+func Table(origin Origin, index string, labels []string) Instruction {
+	var instruction = Instruction{Op: TableOp, Args: []Value{Classify(index)}, Origin: origin}
+	for _, label := range labels {
+		instruction.Args = append(instruction.Args, Value{Kind: Label, Name: label})
+	}
+
+	return instruction
+}
+
+// Returns the definition of the instruction in the instruction table
+func (this Instruction) Definition() (mindustry.Instruction, bool) {
+	return mindustry.FindInstruction(this.Op, this.Mode)
+}
+
+// Returns the variables the instruction writes to
+func (this Instruction) Defs() (defs []Value) {
+	var definition, _ = this.Definition()
+	for i, kind := range argKinds(definition, len(this.Args)) {
+		if kind == mindustry.Out && this.Args[i].IsVariable() {
+			defs = append(defs, this.Args[i])
+		}
+	}
+
+	return defs
+}
+
+// Returns the variables the instruction reads
+func (this Instruction) Uses() (uses []Value) {
+	if this.Op == TableOp {
+		return []Value{this.Args[0]}
+	}
+
+	var definition, _ = this.Definition()
+	for i, kind := range argKinds(definition, len(this.Args)) {
+		if kind == mindustry.In && this.Args[i].IsVariable() {
+			uses = append(uses, this.Args[i])
+		}
+	}
+
+	return uses
+}
+
+// Returns a copy of the instruction with every variable it reads replaced by replace(variable)
+func (this Instruction) ReplaceUses(replace func(Value) Value) Instruction {
+	var kinds = []mindustry.ArgKind{mindustry.In}
+	if this.Op != TableOp {
+		var definition, _ = this.Definition()
+		kinds = argKinds(definition, len(this.Args))
+	}
+
+	this.Args = slices.Clone(this.Args)
+	for i, kind := range kinds {
+		if kind == mindustry.In && this.Args[i].IsVariable() {
+			this.Args[i] = replace(this.Args[i])
+		}
+	}
+
+	return this
+}
+
+// Returns the labels the instruction can jump to
+func (this Instruction) Targets() (labels []string) {
+	if this.Op == LabelOp {
+		return nil
+	}
+
+	for _, arg := range this.Args {
+		if arg.Kind == Label {
+			labels = append(labels, arg.Name)
+		}
+	}
+
+	return labels
+}
+
+// Wether the instruction is a jump that only happens when its condition is true
+//
+// The checker rejects jumps without a condition, a jump that has none anyway is treated as always jumping
+func (this Instruction) IsConditional() bool {
+	return this.Op == "jump" && len(this.Args) > 1 && this.Args[1].Name != "always"
+}
+
+// Wether the next instruction never runs directly after this one
+func (this Instruction) Ends() bool {
+	switch this.Op {
+	case "end", "stop", TableOp:
+		return true
+	case "jump":
+		return !this.IsConditional()
+	}
+
+	return this.WritesCounter()
+}
+
+// Wether the instruction writes to @counter, which jumps to wherever the value points to
+func (this Instruction) WritesCounter() bool {
+	return this.Op != TableOp && slices.ContainsFunc(this.Defs(), func(v Value) bool { return v.Name == "@counter" })
+}
+
+// Returns the number of mlog instructions the instruction is emitted as
+func (this Instruction) Size() int {
+	switch this.Op {
+	case LabelOp:
+		return 0
+	case TableOp:
+		return len(this.Args)
+	}
+
+	return 1
+}
+
+// Returns the mlog parts of the instruction, with the labels as they are
+func (this Instruction) Parts() []string {
+	var parts = []string{this.Op}
+	if this.Mode != "" {
+		parts = append(parts, this.Mode)
+	}
+
+	for _, arg := range this.Args {
+		parts = append(parts, arg.Name)
+	}
+
+	return parts
+}
+
+func (this Instruction) String() string {
+	if this.Op == LabelOp {
+		return this.Args[0].Name + ":"
+	}
+
+	return strings.Join(this.Parts(), " ")
+}
@@ -0,0 +1,147 @@
+package ir
+
+import (
+	"conveycode/compiler/mindustry"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// What an operand of an instruction refers to
+type Kind int
+
+const (
+	_ Kind = iota
+
+	// A variable of the program or a built-in variable of the game such as @unit
+	Variable
+
+	// A temporary variable created by the compiler, which can be renamed or reused
+	Temp
+
+	// A value that never changes: numbers, strings, colors, content and true, false and null
+	Literal
+
+	// The symbolic target of a jump
+	Label
+
+	// A fixed word of the instruction, such as the condition of a jump
+	Word
+)
+
+func (this Kind) String() string {
+	return [...]string{
+		"",
+		"variable",
+		"temp",
+		"literal",
+		"label",
+		"word",
+	}[this]
+}
+
+type Value struct {
+	Kind Kind
+	Name string
+}
+
+func (this Value) String() string {
+	return this.Name
+}
+
+// Wether the value is a variable that instructions can write to, either of the program or a temporary
+func (this Value) IsVariable() bool {
+	return this.Kind == Variable || this.Kind == Temp
+}
+
+// Wether the value is a built-in variable such as @time, which the game changes without an instruction writing to it
+func (this Value) IsBuiltin() bool {
+	return this.Kind == Variable && strings.HasPrefix(this.Name, "@")
+}
+
+// The prefix of the temporary variables the constructor creates
+const TempPrefix = "__tmp"
+
+// Built-in variables that the game changes while the program runs, which makes them variables instead of literals
+var liveBuiltins = []string{
+	"@counter", "@unit", "@this", "@thisx", "@thisy", "@ipt", "@links", "@time", "@tick", "@second", "@minute",
+	"@waveNumber", "@waveTime", "@mapw", "@maph", "@server", "@client", "@clientLocale", "@clientUnit",
+	"@clientName", "@clientTeam", "@clientMobile",
+}
+
+// Returns the value for an operand that is written as the name
+func Classify(name string) Value {
+	switch {
+	case strings.HasPrefix(name, TempPrefix):
+		return Value{Kind: Temp, Name: name}
+	case isLiteral(name):
+		return Value{Kind: Literal, Name: name}
+	}
+
+	return Value{Kind: Variable, Name: name}
+}
+
+func isLiteral(name string) bool {
+	switch {
+	case name == "true" || name == "false" || name == "null":
+		return true
+	case strings.HasPrefix(name, "\"") || strings.HasPrefix(name, "%"):
+		return true
+	case strings.HasPrefix(name, "@"):
+		return !slices.Contains(liveBuiltins, name)
+	}
+
+	var _, ok = ParseNumber(name)
+	return ok
+}
+
+// The ways mlog writes a number: decimal with an optional exponent, hexadecimal and binary.
+// strconv on its own also reads words like inf and nan, which are variable names in mlog
+var numberPattern = regexp.MustCompile(`^-?(?:(?:\d+(?:\.\d*)?|\.\d+)(?:[eE][+-]?\d+)?|0x[0-9a-fA-F]+|0b[01]+)$`)
+
+// Returns the number an operand is written as, false when it is not a number mlog reads
+//
+//	ParseNumber("0x10") // 16
+//	ParseNumber("inf")  // 0, false
+func ParseNumber(name string) (float64, bool) {
+	if !numberPattern.MatchString(name) {
+		return 0, false
+	}
+
+	var digits, negative = strings.CutPrefix(name, "-")
+	var sign = 1.0
+	if negative {
+		sign = -1
+	}
+
+	var base = 0
+	switch {
+	case strings.HasPrefix(digits, "0x"):
+		base = 16
+	case strings.HasPrefix(digits, "0b"):
+		base = 2
+	}
+
+	if base != 0 {
+		var number, err = strconv.ParseUint(digits[2:], base, 64)
+		return sign * float64(number), err == nil
+	}
+
+	var number, err = strconv.ParseFloat(digits, 64)
+	return sign * number, err == nil
+}
+
+// Returns the kind of each operand of the instruction according to the instruction table,
+// operands the table does not know are read
+func argKinds(definition mindustry.Instruction, count int) []mindustry.ArgKind {
+	var kinds = make([]mindustry.ArgKind, count)
+	for i := range kinds {
+		kinds[i] = mindustry.In
+		if i < len(definition.Args) {
+			kinds[i] = definition.Args[i].Kind
+		}
+	}
+
+	return kinds
+}
@@ -0,0 +1,30 @@
+package mindustry
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Packs the channels into an RGBA8888 color the way packcolor does, each channel is between 0 and 1
+//
+//	PackColor(1, 0.5, 0, 1) // 0xff7f00ff
+func PackColor(r, g, b, a float64) uint32 {
+	var channel = func(value float64) uint32 {
+		return uint32(min(max(value, 0), 1) * 255)
+	}
+
+	return channel(r)<<24 | channel(g)<<16 | channel(b)<<8 | channel(a)
+}
+
+// Returns the mlog literal for a packed color,
+// versions before v7 have no color literals and use the number packcolor would produce instead
+//
+//	ColorLiteral(0xff8800cc, V7) // %ff8800cc
+func ColorLiteral(rgba uint32, version Version) string {
+	if version >= V7 {
+		return fmt.Sprintf("%%%08x", rgba)
+	}
+
+	return strconv.FormatFloat(math.Float64frombits(uint64(rgba)), 'g', -1, 64)
+}
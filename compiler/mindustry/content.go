@@ -0,0 +1,98 @@
+package mindustry
+
+import (
+	_ "embed"
+	"slices"
+	"strings"
+)
+
+//go:embed content.txt
+var contentFile string
+
+// The kinds of content that can be looked up by their logic ID
+type ContentType int
+
+const (
+	_ ContentType = iota
+
+	ItemContent
+	LiquidContent
+	UnitContent
+	BlockContent
+)
+
+func (this ContentType) String() string {
+	return [...]string{
+		"",
+		"item",
+		"liquid",
+		"unit",
+		"block",
+	}[this]
+}
+
+// The names of the content of each type, in the order of their logic ID
+var Content = parseContent(contentFile)
+
+// Returns the content type for its name, as used by the lookup instruction
+//
+//	ParseContentType("item") // ItemContent
+func ParseContentType(name string) (ContentType, bool) {
+	for _, typ := range []ContentType{ItemContent, LiquidContent, UnitContent, BlockContent} {
+		if typ.String() == name {
+			return typ, true
+		}
+	}
+
+	return 0, false
+}
+
+// Returns the name of the content with the logic ID, what lookup would return
+//
+//	ContentAt(ItemContent, 0) // "copper"
+func ContentAt(typ ContentType, id int) (string, bool) {
+	if id < 0 || id >= len(Content[typ]) {
+		return "", false
+	}
+
+	return Content[typ][id], true
+}
+
+// Returns the type of the content with the name, the leading @ is optional
+//
+//	FindContent("@flare") // UnitContent
+func FindContent(name string) (ContentType, bool) {
+	name = strings.TrimPrefix(name, "@")
+
+	for typ, names := range Content {
+		if slices.Contains(names, name) {
+			return typ, true
+		}
+	}
+
+	return 0, false
+}
+
+// Reads the content file, which lists the names of each type under a [type] header
+func parseContent(file string) map[ContentType][]string {
+	var content = map[ContentType][]string{}
+	var current ContentType
+
+	for _, line := range strings.Split(file, "\n") {
+		line = strings.TrimSpace(line)
+
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+		case strings.HasPrefix(line, "["):
+			var typ, ok = ParseContentType(strings.Trim(line, "[]"))
+			if !ok {
+				panic("unknown content type " + line)
+			}
+			current = typ
+		default:
+			content[current] = append(content[current], line)
+		}
+	}
+
+	return content
+}
@@ -0,0 +1,25 @@
+package mindustry
+
+import (
+	"slices"
+	"strings"
+)
+
+// Properties of a building that can be set with the control instruction
+var Controls []string = []string{
+	"enabled",
+	"config",
+	"color",
+}
+
+// Returns the mode of the control instruction that sets the property, the leading @ is optional
+//
+//	ControlOf("@enabled") // control enabled building value
+func ControlOf(property string) (Instruction, bool) {
+	var name = strings.TrimPrefix(property, "@")
+	if !slices.Contains(Controls, name) {
+		return Instruction{}, false
+	}
+
+	return FindInstruction("control", name)
+}
@@ -0,0 +1,401 @@
+package mindustry
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// What an operand of an instruction is used for
+type ArgKind int
+
+const (
+	_ ArgKind = iota
+
+	// A value the instruction reads, either a variable or a literal
+	In
+
+	// A variable the instruction writes to
+	Out
+
+	// A fixed word, such as the sort of a radar or the condition of a jump
+	Word
+
+	// The index of the instruction a jump goes to
+	Label
+)
+
+func (this ArgKind) String() string {
+	return [...]string{
+		"",
+		"in",
+		"out",
+		"word",
+		"label",
+	}[this]
+}
+
+type Arg struct {
+	Name string
+	Kind ArgKind
+}
+
+// The definition of an mlog instruction, or of one mode of an instruction that has modes
+type Instruction struct {
+	Opcode string
+
+	// The sub mode, empty for instructions that do not have one
+	Mode string
+
+	// The operands after the mode
+	Args []Arg
+
+	// The number of operands the game always writes after the mode, when it is more than the arguments
+	// the remaining operands are padded with 0. Zero when it is the number of arguments
+	Slots int
+
+	// The first version of the game that has the instruction, zero when every version has it
+	Version Version
+
+	// Wether the instruction is only available on world processors
+	World bool
+}
+
+// Returns the number of operands the instruction is written with after the mode
+func (this Instruction) Operands() int {
+	return max(this.Slots, len(this.Args))
+}
+
+// Returns the number of operands that have to be written after the mode,
+// a jump that always jumps only needs its target and condition since it compares nothing
+//
+//	Required([]string{"loop", "always"}) // 2 for jump
+func (this Instruction) Required(operands []string) int {
+	if this.Opcode == "jump" && len(operands) > 1 && operands[1] == "always" {
+		return 2
+	}
+
+	return len(this.Args)
+}
+
+// Returns the arguments of the given kind
+func (this Instruction) ArgsOf(kind ArgKind) (args []Arg) {
+	for _, arg := range this.Args {
+		if arg.Kind == kind {
+			args = append(args, arg)
+		}
+	}
+
+	return args
+}
+
+// The conditions a jump compares with
+var Conditions = []string{"equal", "notEqual", "lessThan", "lessThanEq", "greaterThan", "greaterThanEq", "strictEqual", "always"}
+
+// The most instructions a processor accepts, the game rejects longer programs
+const MaxInstructions = 1000
+
+// Every instruction of the game, in the order of the processor's instruction menu
+var Instructions = slices.Concat(
+	//#region Input and output
+	[]Instruction{
+		{Opcode: "read", Args: join(out("result"), in("cell", "address"))},
+		{Opcode: "write", Args: in("value", "cell", "address")},
+	},
+	modes(Instruction{Opcode: "draw", Slots: 6},
+		Instruction{Mode: "clear", Args: in("r", "g", "b")},
+		Instruction{Mode: "color", Args: in("r", "g", "b", "a")},
+		Instruction{Mode: "col", Args: in("color"), Version: V7},
+		Instruction{Mode: "stroke", Args: in("width")},
+		Instruction{Mode: "line", Args: in("x", "y", "x2", "y2")},
+		Instruction{Mode: "rect", Args: in("x", "y", "width", "height")},
+		Instruction{Mode: "lineRect", Args: in("x", "y", "width", "height")},
+		Instruction{Mode: "poly", Args: in("x", "y", "sides", "radius", "rotation")},
+		Instruction{Mode: "linePoly", Args: in("x", "y", "sides", "radius", "rotation")},
+		Instruction{Mode: "triangle", Args: in("x", "y", "x2", "y2", "x3", "y3")},
+		Instruction{Mode: "image", Args: in("x", "y", "image", "size", "rotation")},
+		Instruction{Mode: "print", Args: join(in("x", "y"), word("align")), Version: V8},
+		Instruction{Mode: "translate", Args: in("x", "y"), Version: V8},
+		Instruction{Mode: "scale", Args: in("x", "y"), Version: V8},
+		Instruction{Mode: "rotate", Args: in("degrees"), Version: V8},
+		Instruction{Mode: "reset", Version: V8},
+	),
+	[]Instruction{
+		{Opcode: "print", Args: in("value")},
+		{Opcode: "printchar", Args: in("value"), Version: V8},
+		{Opcode: "format", Args: in("value"), Version: V8},
+	},
+	//#endregion
+
+	//#region Block control
+	[]Instruction{
+		{Opcode: "drawflush", Args: in("display")},
+		{Opcode: "printflush", Args: in("building")},
+		{Opcode: "getlink", Args: join(out("result"), in("index"))},
+	},
+	modes(Instruction{Opcode: "control", Slots: 5},
+		Instruction{Mode: "enabled", Args: in("building", "value")},
+		Instruction{Mode: "shoot", Args: in("building", "x", "y", "shoot")},
+		Instruction{Mode: "shootp", Args: in("building", "unit", "shoot")},
+		Instruction{Mode: "config", Args: in("building", "value")},
+		Instruction{Mode: "color", Args: in("building", "color"), Version: V7},
+	),
+	[]Instruction{
+		{Opcode: "radar", Args: join(word("target1", "target2", "target3", "sort"), in("building", "order"), out("result"))},
+		{Opcode: "sensor", Args: join(out("result"), in("object", "property"))},
+	},
+	//#endregion
+
+	//#region Operations
+	[]Instruction{
+		{Opcode: "set", Args: join(out("result"), in("value"))},
+	},
+	modes(Instruction{Opcode: "op", Args: join(out("result"), in("a", "b"))},
+		Instruction{Mode: "add"}, Instruction{Mode: "sub"}, Instruction{Mode: "mul"}, Instruction{Mode: "div"},
+		Instruction{Mode: "idiv"}, Instruction{Mode: "mod"}, Instruction{Mode: "emod", Version: V8}, Instruction{Mode: "pow"},
+		Instruction{Mode: "equal"}, Instruction{Mode: "notEqual"}, Instruction{Mode: "land"},
+		Instruction{Mode: "lessThan"}, Instruction{Mode: "lessThanEq"}, Instruction{Mode: "greaterThan"},
+		Instruction{Mode: "greaterThanEq"}, Instruction{Mode: "strictEqual"},
+		Instruction{Mode: "shl"}, Instruction{Mode: "shr"}, Instruction{Mode: "ushr", Version: V8},
+		Instruction{Mode: "or"}, Instruction{Mode: "and"}, Instruction{Mode: "xor"}, Instruction{Mode: "not"},
+		Instruction{Mode: "max"}, Instruction{Mode: "min"}, Instruction{Mode: "angle"}, Instruction{Mode: "angleDiff", Version: V7},
+		Instruction{Mode: "len"}, Instruction{Mode: "noise"}, Instruction{Mode: "abs"}, Instruction{Mode: "sign", Version: V8},
+		Instruction{Mode: "log"}, Instruction{Mode: "logn", Version: V8}, Instruction{Mode: "log10"},
+		Instruction{Mode: "floor"}, Instruction{Mode: "ceil"}, Instruction{Mode: "round", Version: V8}, Instruction{Mode: "sqrt"},
+		Instruction{Mode: "rand"}, Instruction{Mode: "sin"}, Instruction{Mode: "cos"}, Instruction{Mode: "tan"},
+		Instruction{Mode: "asin"}, Instruction{Mode: "acos"}, Instruction{Mode: "atan"},
+	),
+	[]Instruction{
+		{Opcode: "select", Args: join(out("result"), word("condition"), in("a", "b", "ifTrue", "ifFalse")), Version: V8},
+	},
+	modes(Instruction{Opcode: "lookup", Args: join(out("result"), in("index")), Version: V7},
+		Instruction{Mode: "block"},
+		Instruction{Mode: "unit"},
+		Instruction{Mode: "item"},
+		Instruction{Mode: "liquid"},
+		Instruction{Mode: "team", Version: V8},
+	),
+	[]Instruction{
+		{Opcode: "packcolor", Args: join(out("result"), in("r", "g", "b", "a")), Version: V7},
+		{Opcode: "unpackcolor", Args: join(out("r", "g", "b", "a"), in("color")), Version: V8},
+	},
+	//#endregion
+
+	//#region Flow control
+	[]Instruction{
+		{Opcode: "wait", Args: in("seconds"), Version: V7},
+		{Opcode: "stop", Version: V7},
+		{Opcode: "end"},
+		{Opcode: "jump", Args: join([]Arg{{Name: "target", Kind: Label}}, word("condition"), in("a", "b"))},
+	},
+	//#endregion
+
+	//#region Unit control
+	[]Instruction{
+		{Opcode: "ubind", Args: in("type")},
+	},
+	modes(Instruction{Opcode: "ucontrol", Slots: 5},
+		Instruction{Mode: "idle"},
+		Instruction{Mode: "stop"},
+		Instruction{Mode: "move", Args: in("x", "y")},
+		Instruction{Mode: "approach", Args: in("x", "y", "radius")},
+		Instruction{Mode: "pathfind", Args: in("x", "y")},
+		Instruction{Mode: "autoPathfind", Version: V7},
+		Instruction{Mode: "boost", Args: in("enable")},
+		Instruction{Mode: "target", Args: in("x", "y", "shoot")},
+		Instruction{Mode: "targetp", Args: in("unit", "shoot")},
+		Instruction{Mode: "itemDrop", Args: in("building", "amount")},
+		Instruction{Mode: "itemTake", Args: in("building", "item", "amount")},
+		Instruction{Mode: "payDrop"},
+		Instruction{Mode: "payTake", Args: in("takeUnits")},
+		Instruction{Mode: "payEnter", Version: V7},
+		Instruction{Mode: "mine", Args: in("x", "y")},
+		Instruction{Mode: "flag", Args: in("value")},
+		Instruction{Mode: "build", Args: in("x", "y", "block", "rotation", "config")},
+		Instruction{Mode: "getBlock", Args: join(in("x", "y"), out("type", "building", "floor"))},
+		Instruction{Mode: "within", Args: join(in("x", "y", "radius"), out("result"))},
+		Instruction{Mode: "unbind"},
+	),
+	[]Instruction{
+		{Opcode: "uradar", Args: join(word("target1", "target2", "target3", "sort"), in("unused", "order"), out("result"))},
+	},
+	modes(Instruction{Opcode: "ulocate", Args: join(word("group"), in("enemy", "ore"), out("x", "y", "found", "building"))},
+		Instruction{Mode: "building"},
+		Instruction{Mode: "ore"},
+		Instruction{Mode: "spawn"},
+		Instruction{Mode: "damaged"},
+	),
+	//#endregion
+
+	//#region World
+	[]Instruction{
+		{Opcode: "getblock", Args: join(word("layer"), out("result"), in("x", "y")), Version: V7, World: true},
+		{Opcode: "setblock", Args: join(word("layer"), in("block", "x", "y", "team", "rotation")), Version: V7, World: true},
+		{Opcode: "spawn", Args: join(in("type", "x", "y", "rotation", "team"), out("result")), Version: V7, World: true},
+		{Opcode: "status", Args: join(word("clear", "effect"), in("unit", "duration")), Version: V7, World: true},
+		{Opcode: "weathersense", Args: join(out("result"), in("weather")), Version: V8, World: true},
+		{Opcode: "weatherset", Args: in("weather", "state"), Version: V8, World: true},
+		{Opcode: "spawnwave", Args: in("x", "y", "natural"), Version: V7, World: true},
+		{Opcode: "setrule", Args: join(word("rule"), in("value", "x", "y", "width", "height")), Version: V7, World: true},
+		{Opcode: "message", Args: join(word("kind"), in("duration")), Version: V7, World: true},
+	},
+	modes(Instruction{Opcode: "cutscene", Slots: 4, Version: V7, World: true},
+		Instruction{Mode: "pan", Args: in("x", "y", "speed")},
+		Instruction{Mode: "zoom", Args: in("level")},
+		Instruction{Mode: "stop"},
+	),
+	[]Instruction{
+		{Opcode: "effect", Args: join(word("effect"), in("x", "y", "rotation", "color", "data")), Version: V7, World: true},
+		{Opcode: "explosion", Args: in("team", "x", "y", "radius", "damage", "air", "ground", "pierce"), Version: V7, World: true},
+		{Opcode: "setrate", Args: in("rate"), Version: V7, World: true},
+		{Opcode: "fetch", Args: join(word("type"), out("result"), in("team", "index", "extra")), Version: V7, World: true},
+		{Opcode: "sync", Args: in("variable"), Version: V7, World: true},
+		{Opcode: "getflag", Args: join(out("result"), in("flag")), Version: V7, World: true},
+		{Opcode: "setflag", Args: in("flag", "value"), Version: V7, World: true},
+		{Opcode: "setprop", Args: in("property", "object", "value"), Version: V7, World: true},
+		{Opcode: "playsound", Args: in("positional", "sound", "volume", "pitch", "pan", "x", "y", "limit"), Version: V8, World: true},
+		{Opcode: "setmarker", Args: join(word("property"), in("id", "a", "b", "c")), Version: V8, World: true},
+		{Opcode: "makemarker", Args: join(word("type"), in("id", "x", "y", "replace")), Version: V8, World: true},
+		{Opcode: "localeprint", Args: in("key"), Version: V8},
+		{Opcode: "clientdata", Args: in("channel", "value", "reliable"), Version: V8, World: true},
+	},
+	//#endregion
+)
+
+var instructionIndex = indexInstructions()
+
+// Returns the definition of the instruction,
+// mode is empty for instructions that do not have modes
+//
+//	FindInstruction("ucontrol", "move")
+//	FindInstruction("sensor", "")
+func FindInstruction(opcode string, mode string) (Instruction, bool) {
+	var instruction, ok = instructionIndex[opcode+" "+mode]
+	return instruction, ok
+}
+
+// Wether the operand after the opcode selects a mode of the instruction, as in ucontrol move
+func HasModes(opcode string) bool {
+	_, ok := instructionIndex[opcode+" "]
+	return !ok && slices.ContainsFunc(Instructions, func(i Instruction) bool { return i.Opcode == opcode })
+}
+
+// Check that an mlog instruction, split into its parts, exists on the target
+// and is given no more operands than the game reads and no fewer than it needs
+//
+//	Validate([]string{"ucontrol", "move", "x", "y", "0", "0", "0"}, target)
+func Validate(parts []string, target Target) error {
+	var opcode, mode = parts[0], ""
+	var operands = parts[1:]
+
+	if HasModes(opcode) {
+		if len(operands) == 0 {
+			return fmt.Errorf("%s is missing its mode", opcode)
+		}
+		mode, operands = operands[0], operands[1:]
+	}
+
+	var instruction, ok = FindInstruction(opcode, mode)
+	switch {
+	case !ok && mode != "":
+		return fmt.Errorf("%s has no mode %s", opcode, mode)
+	case !ok:
+		return fmt.Errorf("unknown instruction %s", opcode)
+	case instruction.World && target.Processor != WorldProcessor:
+		return fmt.Errorf("%s can only be used on a world processor", opcode)
+	case !target.Supports(instruction.Version):
+		return fmt.Errorf("%s needs Mindustry %s or newer, the target is %s", opcode, instruction.Version, target.GameVersion())
+	case len(operands) > instruction.Operands():
+		return fmt.Errorf("%s takes %d operands but got %d", opcode, instruction.Operands(), len(operands))
+	case len(operands) < instruction.Required(operands):
+		return fmt.Errorf("%s needs %d operands but got %d", opcode, instruction.Required(operands), len(operands))
+	case opcode == "jump" && !slices.Contains(Conditions, operands[1]):
+		return fmt.Errorf("jump has no condition %s", operands[1])
+	}
+
+	return nil
+}
+
+// Splits an mlog instruction into its parts, a string is one part even if it contains spaces
+//
+//	Fields(`print "a b"`) // ["print", "\"a b\""]
+func Fields(line string) (parts []string) {
+	var current strings.Builder
+	var quoted = false
+	var escaped = false
+
+	for _, char := range line {
+		switch {
+		case quoted && escaped:
+			escaped = false
+			current.WriteRune(char)
+		case quoted && char == '\\':
+			escaped = true
+			current.WriteRune(char)
+		case char == '"':
+			quoted = !quoted
+			current.WriteRune(char)
+		case char == ' ' && !quoted:
+			if current.Len() > 0 {
+				parts = append(parts, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(char)
+		}
+	}
+
+	if current.Len() > 0 {
+		parts = append(parts, current.String())
+	}
+
+	return parts
+}
+
+//#region Utilities
+
+func indexInstructions() map[string]Instruction {
+	var index = make(map[string]Instruction, len(Instructions))
+	for _, instruction := range Instructions {
+		index[instruction.Opcode+" "+instruction.Mode] = instruction
+	}
+
+	return index
+}
+
+// Fills in the opcode and the fields the modes share, a mode without arguments takes those of the base
+func modes(base Instruction, modes ...Instruction) []Instruction {
+	for i, mode := range modes {
+		mode.Opcode = base.Opcode
+		mode.Slots = base.Slots
+		mode.World = base.World
+		mode.Version = max(mode.Version, base.Version)
+
+		if mode.Args == nil {
+			mode.Args = base.Args
+		}
+
+		modes[i] = mode
+	}
+
+	return modes
+}
+
+func args(kind ArgKind, names []string) []Arg {
+	var args = make([]Arg, len(names))
+	for i, name := range names {
+		args[i] = Arg{Name: name, Kind: kind}
+	}
+
+	return args
+}
+
+func in(names ...string) []Arg   { return args(In, names) }
+func out(names ...string) []Arg  { return args(Out, names) }
+func word(names ...string) []Arg { return args(Word, names) }
+
+func join(groups ...[]Arg) []Arg {
+	return slices.Concat(groups...)
+}
+
+//#endregion
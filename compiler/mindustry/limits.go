@@ -0,0 +1,5 @@
+package mindustry
+
+// The number of draw commands a processor buffers before drawflush,
+// commands past this are dropped by the game
+const GraphicsBuffer = 256
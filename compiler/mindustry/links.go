@@ -0,0 +1,13 @@
+package mindustry
+
+import "regexp"
+
+var linkName = regexp.MustCompile(`^[a-z]+[0-9]+$`)
+
+// Check if the name has the form the game gives to buildings that are linked to a processor
+//
+//	IsLinkName("message1") // true
+//	IsLinkName("message") // false
+func IsLinkName(name string) bool {
+	return linkName.MatchString(name)
+}
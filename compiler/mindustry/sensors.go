@@ -0,0 +1,83 @@
+package mindustry
+
+import (
+	"slices"
+	"strings"
+)
+
+// Every property that can be read with the sensor instruction (LAccess in the game)
+var Sensors []string = []string{
+	"totalItems",
+	"firstItem",
+	"totalLiquids",
+	"totalPower",
+	"itemCapacity",
+	"liquidCapacity",
+	"powerCapacity",
+	"powerNetStored",
+	"powerNetCapacity",
+	"powerNetIn",
+	"powerNetOut",
+	"ammo",
+	"ammoCapacity",
+	"currentAmmoType",
+	"memoryCapacity",
+	"health",
+	"maxHealth",
+	"heat",
+	"shield",
+	"armor",
+	"efficiency",
+	"progress",
+	"timescale",
+	"rotation",
+	"x",
+	"y",
+	"velocityX",
+	"velocityY",
+	"shootX",
+	"shootY",
+	"cameraX",
+	"cameraY",
+	"cameraWidth",
+	"cameraHeight",
+	"size",
+	"solid",
+	"dead",
+	"range",
+	"shooting",
+	"boosting",
+	"mineX",
+	"mineY",
+	"mining",
+	"speed",
+	"team",
+	"type",
+	"flag",
+	"controlled",
+	"controller",
+	"name",
+	"payloadCount",
+	"payloadType",
+	"totalPayload",
+	"payloadCapacity",
+	"id",
+	"enabled",
+	"config",
+	"color",
+}
+
+// Items and liquids can be sensed as well, this returns the amount stored
+var Items []string = Content[ItemContent]
+
+var Liquids []string = Content[LiquidContent]
+
+// Check if the property can be sensed, the leading @ is optional
+//
+//	IsSensor("@health") // true
+//	IsSensor("copper") // true
+func IsSensor(property string) bool {
+	property = strings.TrimPrefix(property, "@")
+
+	return slices.Contains(Sensors, property) || slices.Contains(Items, property) || slices.Contains(Liquids, property)
+}
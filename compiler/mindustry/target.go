@@ -0,0 +1,86 @@
+package mindustry
+
+import "fmt"
+
+// The kind of processor a program runs on
+type Processor int
+
+const (
+	// Regular processors that players build
+	LogicProcessor Processor = iota
+
+	// Processors placed by map makers, which have access to the world instructions
+	WorldProcessor
+)
+
+func (this Processor) String() string {
+	return [...]string{
+		"logic",
+		"world",
+	}[this]
+}
+
+// A release of Mindustry with its own set of instructions
+type Version int
+
+const (
+	V6 Version = 6
+	V7 Version = 7
+
+	// Build 146 and newer
+	V8 Version = 8
+
+	Latest = V8
+)
+
+func (this Version) String() string {
+	return fmt.Sprintf("v%d", this)
+}
+
+// What the program is compiled for
+type Target struct {
+	Processor Processor
+
+	// The version of the game, the zero value is the latest version
+	Version Version
+}
+
+// Returns the version of the game the program is compiled for
+func (this Target) GameVersion() Version {
+	if this.Version == 0 {
+		return Latest
+	}
+
+	return this.Version
+}
+
+// Wether the game version of the target has the features of the given version
+func (this Target) Supports(version Version) bool {
+	return this.GameVersion() >= version
+}
+
+// Parse the value of the --target option
+//
+//	ParseTarget("world") // Target{Processor: WorldProcessor}
+func ParseTarget(value string) (Target, error) {
+	for _, processor := range []Processor{LogicProcessor, WorldProcessor} {
+		if processor.String() == value {
+			return Target{Processor: processor}, nil
+		}
+	}
+
+	return Target{}, fmt.Errorf("unknown target %s, expected logic or world", value)
+}
+
+// Parse the value of the --version option
+//
+//	ParseVersion("v7") // V7
+func ParseVersion(value string) (Version, error) {
+	for _, version := range []Version{V6, V7, V8} {
+		if version.String() == value {
+			return version, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown version %s, expected v6, v7 or v8", value)
+}
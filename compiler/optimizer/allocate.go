@@ -0,0 +1,116 @@
+package optimizer
+
+import (
+	"conveycode/compiler/ir"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// The instructions from the first write to a temporary until its last read,
+// in the order the program is emitted
+type interval struct {
+	temp  string
+	start int
+	end   int
+}
+
+// Rename the temporaries so that temporaries that are never live at the same time share a name
+//
+//	op add __tmp0 a 1
+//	op mul b __tmp0 2
+//	op add __tmp1 a 3 // op add __tmp0 a 3
+//
+// Each temporary gets the lowest name that is free when it is first written, a name is free
+// again after the last instruction that reads it. Since an instruction reads its operands
+// before it writes its results, a temporary can be written by the instruction that last reads another
+func allocate(program *ir.Program) {
+	var code []ir.Instruction
+	var intervals = map[string]*interval{}
+
+	var extend = func(temp string, position int) {
+		if current, ok := intervals[temp]; ok {
+			current.start, current.end = min(current.start, position), max(current.end, position)
+		} else {
+			intervals[temp] = &interval{temp: temp, start: position, end: position}
+		}
+	}
+
+	var liveOut = liveness(program)
+	for _, block := range program.Blocks {
+		var first = len(code)
+		code = append(code, block.Instructions...)
+
+		var live = liveOut[block]
+		for j := len(block.Instructions) - 1; j >= 0; j-- {
+			var position = first + j
+			for temp := range live {
+				if ir.Classify(temp).Kind == ir.Temp {
+					extend(temp, position)
+				}
+			}
+
+			step(live, block.Instructions[j])
+			for _, value := range slices.Concat(block.Instructions[j].Defs(), block.Instructions[j].Uses()) {
+				if value.Kind == ir.Temp {
+					extend(value.Name, position)
+				}
+			}
+		}
+	}
+
+	var sorted = slices.SortedFunc(maps.Values(intervals), func(a *interval, b *interval) int {
+		if a.start != b.start {
+			return a.start - b.start
+		}
+		return compareNames(a.temp, b.temp)
+	})
+
+	var names = map[string]string{}
+	var active = map[string]*interval{}
+
+	for _, current := range sorted {
+		var writes = code[current.start].Defs()
+		for name, other := range active {
+			var expired = other.end < current.start
+			if other.end == current.start {
+				expired = !slices.ContainsFunc(writes, func(v ir.Value) bool { return v.Name == other.temp })
+			}
+			if expired {
+				delete(active, name)
+			}
+		}
+
+		var name string
+		for i := 0; ; i++ {
+			name = fmt.Sprintf("%s%d", ir.TempPrefix, i)
+			if _, taken := active[name]; !taken {
+				break
+			}
+		}
+
+		active[name] = current
+		names[current.temp] = name
+	}
+
+	for _, block := range program.Blocks {
+		for i, instruction := range block.Instructions {
+			instruction.Args = slices.Clone(instruction.Args)
+			for a, arg := range instruction.Args {
+				if arg.Kind == ir.Temp {
+					instruction.Args[a].Name = names[arg.Name]
+				}
+			}
+			block.Instructions[i] = instruction
+		}
+	}
+}
+
+// Orders temporaries by their number, so that __tmp2 comes before __tmp10
+func compareNames(a string, b string) int {
+	if len(a) != len(b) {
+		return len(a) - len(b)
+	}
+	return strings.Compare(a, b)
+}
@@ -0,0 +1,148 @@
+package optimizer
+
+import (
+	"conveycode/compiler/ir"
+	"slices"
+	"strconv"
+)
+
+// Instructions that only compute their results, they can be removed when nothing reads the results.
+// sensor and read are left out since reading a building can have effects in the game
+var pureInstructions = []string{"set", "op", "select", "lookup", "packcolor", "unpackcolor", "getlink"}
+
+// Remove the code that can never run and the instructions whose temporary results are never read
+//
+//	stop()
+//	print("never") // removed
+//
+// Jumps with a constant condition are resolved first, so the branch that is never taken is removed as well
+func eliminate(program *ir.Program) {
+	for _, block := range program.Blocks {
+		var instructions []ir.Instruction
+		for _, instruction := range block.Instructions {
+			if instruction, keep := resolveJump(instruction); keep {
+				instructions = append(instructions, instruction)
+			}
+		}
+		block.Instructions = instructions
+	}
+
+	program.Link()
+	removeUnreachable(program)
+	removeUnused(program)
+	program.Link()
+}
+
+// Returns the jump as an unconditional jump when its condition is always true,
+// false when the condition is never true and the jump can be removed
+//
+//	jump L lessThan 3 5        // jump L always
+//	op add @counter @counter 1 // jump slot1 always
+func resolveJump(instruction ir.Instruction) (ir.Instruction, bool) {
+	var always = func(label ir.Value) ir.Instruction {
+		return ir.Instruction{Op: "jump", Args: []ir.Value{label, {Kind: ir.Word, Name: "always"}}, Origin: instruction.Origin}
+	}
+
+	switch {
+	case instruction.Op == ir.TableOp:
+		var index, err = strconv.Atoi(instruction.Args[0].Name)
+		if instruction.Args[0].Kind == ir.Literal && err == nil && index >= 0 && index < len(instruction.Args)-1 {
+			return always(instruction.Args[1+index]), true
+		}
+	case instruction.IsConditional():
+		var truth, ok = jumpTruth(instruction.Args[1].Name, instruction.Args[2], instruction.Args[3])
+		if ok && !truth {
+			return instruction, false
+		}
+		if ok {
+			return always(instruction.Args[0]), true
+		}
+	}
+
+	return instruction, true
+}
+
+// Returns wether the condition holds for the operands, false for ok when it depends on the values at runtime
+func jumpTruth(condition string, a ir.Value, b ir.Value) (truth bool, ok bool) {
+	var x, xOk = number(a)
+	var y, yOk = number(b)
+	if xOk && yOk {
+		return compare(condition, x, y)
+	}
+
+	//? Other literals are only known to be equal to themselves
+	var same = a.Kind == ir.Literal && a == b
+	switch condition {
+	case "equal", "strictEqual", "lessThanEq", "greaterThanEq":
+		return true, same
+	case "notEqual", "lessThan", "greaterThan":
+		return false, same
+	}
+
+	return false, false
+}
+
+// Remove the blocks that no path from the first block reaches
+func removeUnreachable(program *ir.Program) {
+	if len(program.Blocks) == 0 {
+		return
+	}
+
+	var reached = map[*ir.Block]bool{}
+	var pending = []*ir.Block{program.Blocks[0]}
+
+	for len(pending) > 0 {
+		var block = pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+
+		if reached[block] {
+			continue
+		}
+		reached[block] = true
+		pending = append(pending, block.Succs...)
+	}
+
+	program.Blocks = slices.DeleteFunc(program.Blocks, func(block *ir.Block) bool { return !reached[block] })
+	program.Link()
+}
+
+// Remove the pure instructions that write to temporaries nothing reads,
+// repeated since removing one can leave the temporaries it read unused as well.
+// Variables of the program are kept, other processors and the game can still read them by name
+func removeUnused(program *ir.Program) {
+	for changed := true; changed; {
+		changed = false
+
+		var read = map[string]bool{}
+		for _, block := range program.Blocks {
+			for _, instruction := range block.Instructions {
+				for _, use := range instruction.Uses() {
+					read[use.Name] = true
+				}
+			}
+		}
+
+		for _, block := range program.Blocks {
+			var before = len(block.Instructions)
+			block.Instructions = slices.DeleteFunc(block.Instructions, func(instruction ir.Instruction) bool {
+				return isUnused(instruction, read)
+			})
+			changed = changed || len(block.Instructions) != before
+		}
+	}
+}
+
+func isUnused(instruction ir.Instruction, read map[string]bool) bool {
+	var defs = instruction.Defs()
+	if !slices.Contains(pureInstructions, instruction.Op) || len(defs) == 0 {
+		return false
+	}
+
+	for _, def := range defs {
+		if read[def.Name] || def.Kind != ir.Temp {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,168 @@
+package optimizer
+
+import (
+	"conveycode/compiler/ir"
+	"math"
+	"slices"
+	"strconv"
+)
+
+// The op modes that ignore their second operand
+var unaryModes = []string{"abs", "floor", "ceil", "sqrt", "not"}
+
+// Returns the number a literal stands for, true and false are 1 and 0
+//
+//	number("0x10") // 16
+//	number("true") // 1
+func number(value ir.Value) (float64, bool) {
+	if value.Kind != ir.Literal {
+		return 0, false
+	}
+
+	switch value.Name {
+	case "true":
+		return 1, true
+	case "false":
+		return 0, true
+	}
+
+	return ir.ParseNumber(value.Name)
+}
+
+// Returns the literal for a number, in the shortest form mlog reads
+//
+//	literal(8)   // 8
+//	literal(0.5) // 0.5
+func literal(number float64) ir.Value {
+	return ir.Value{Kind: ir.Literal, Name: strconv.FormatFloat(number, 'f', -1, 64)}
+}
+
+// Returns the result of an op mode on numbers the way the processor computes it,
+// false for modes that are random or not worth folding and for results that are not finite
+//
+//	evaluate("add", 3, 5) // 8
+func evaluate(mode string, a float64, b float64) (float64, bool) {
+	var result float64
+
+	switch mode {
+	case "add":
+		result = a + b
+	case "sub":
+		result = a - b
+	case "mul":
+		result = a * b
+	case "div":
+		result = a / b
+	case "idiv":
+		result = math.Floor(a / b)
+	case "mod":
+		result = math.Mod(a, b)
+	case "pow":
+		result = math.Pow(a, b)
+	case "max":
+		result = math.Max(a, b)
+	case "min":
+		result = math.Min(a, b)
+	case "abs":
+		result = math.Abs(a)
+	case "floor":
+		result = math.Floor(a)
+	case "ceil":
+		result = math.Ceil(a)
+	case "sqrt":
+		result = math.Sqrt(a)
+	case "shl":
+		result = float64(int64(a) << shift(b))
+	case "shr":
+		result = float64(int64(a) >> shift(b))
+	case "or":
+		result = float64(int64(a) | int64(b))
+	case "and":
+		result = float64(int64(a) & int64(b))
+	case "xor":
+		result = float64(int64(a) ^ int64(b))
+	case "not":
+		result = float64(^int64(a))
+	case "land":
+		result = boolean(a != 0 && b != 0)
+	default:
+		var truth, ok = compare(mode, a, b)
+		if !ok {
+			return 0, false
+		}
+		result = boolean(truth)
+	}
+
+	return result, !math.IsNaN(result) && !math.IsInf(result, 0)
+}
+
+// Returns the result of a jump or op condition on numbers, false when the condition is not a comparison
+//
+//	compare("lessThan", 3, 5) // true
+func compare(condition string, a float64, b float64) (truth bool, ok bool) {
+	switch condition {
+	case "equal":
+		//? The processor compares with a tolerance, so 0.1 + 0.2 equals 0.3
+		return math.Abs(a-b) < 0.000001, true
+	case "notEqual":
+		return math.Abs(a-b) >= 0.000001, true
+	case "lessThan":
+		return a < b, true
+	case "lessThanEq":
+		return a <= b, true
+	case "greaterThan":
+		return a > b, true
+	case "greaterThanEq":
+		return a >= b, true
+	case "strictEqual":
+		return a == b, true
+	case "always":
+		return true, true
+	}
+
+	return false, false
+}
+
+// Returns the amount a value is shifted by, the game shifts longs so only the lowest 6 bits count
+//
+//	shift(-1) // 63
+func shift(amount float64) uint64 {
+	return uint64(int64(amount)) & 63
+}
+
+func boolean(truth bool) float64 {
+	if truth {
+		return 1
+	}
+	return 0
+}
+
+// Returns the instruction as a set of its result when every operand of the op is a number
+//
+//	op add z 3 5 // set z 8
+func fold(instruction ir.Instruction) (ir.Instruction, bool) {
+	if instruction.Op != "op" || len(instruction.Args) < 3 {
+		return instruction, false
+	}
+
+	var a, aOk = number(instruction.Args[1])
+	var b, bOk = number(instruction.Args[2])
+	if slices.Contains(unaryModes, instruction.Mode) {
+		b, bOk = 0, true
+	}
+
+	if !aOk || !bOk {
+		return instruction, false
+	}
+
+	var result, ok = evaluate(instruction.Mode, a, b)
+	if !ok {
+		return instruction, false
+	}
+
+	return ir.Instruction{
+		Op:     "set",
+		Args:   []ir.Value{instruction.Args[0], literal(result)},
+		Origin: instruction.Origin,
+	}, true
+}
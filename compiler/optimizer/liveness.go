@@ -0,0 +1,54 @@
+package optimizer
+
+import (
+	"conveycode/compiler/ir"
+	"maps"
+)
+
+// The variables that are read later before they are written again
+type liveSet map[string]bool
+
+// Returns the variables that are live at the end of each block
+//
+// A variable is live when some path from there reads it before writing to it.
+// The sets flow backwards along the control-flow graph until they stop changing
+func liveness(program *ir.Program) map[*ir.Block]liveSet {
+	var liveIn = map[*ir.Block]liveSet{}
+	var liveOut = map[*ir.Block]liveSet{}
+
+	for changed := true; changed; {
+		changed = false
+
+		for i := len(program.Blocks) - 1; i >= 0; i-- {
+			var block = program.Blocks[i]
+
+			var out = liveSet{}
+			for _, succ := range block.Succs {
+				maps.Copy(out, liveIn[succ])
+			}
+			liveOut[block] = out
+
+			var in = maps.Clone(out)
+			for j := len(block.Instructions) - 1; j >= 0; j-- {
+				step(in, block.Instructions[j])
+			}
+
+			if !maps.Equal(in, liveIn[block]) {
+				liveIn[block] = in
+				changed = true
+			}
+		}
+	}
+
+	return liveOut
+}
+
+// Update the variables that are live after the instruction to those that are live before it
+func step(live liveSet, instruction ir.Instruction) {
+	for _, def := range instruction.Defs() {
+		delete(live, def.Name)
+	}
+	for _, use := range instruction.Uses() {
+		live[use.Name] = true
+	}
+}
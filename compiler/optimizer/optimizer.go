@@ -0,0 +1,31 @@
+package optimizer
+
+import (
+	"conveycode/compiler/ir"
+)
+
+// A rewrite of the program that keeps what the program does
+type Pass func(program *ir.Program)
+
+var (
+	Propagate Pass = propagate
+	Eliminate Pass = eliminate
+	Peephole  Pass = peephole
+	Allocate  Pass = allocate
+)
+
+// Every pass in the order they run, later passes clean up what earlier ones leave behind
+var Passes = []Pass{Propagate, Eliminate, Peephole, Allocate}
+
+// Rewrite the program to do the same with fewer instructions, the passes run in order
+//
+// Returns the number of instructions that were removed
+func Optimize(program *ir.Program, passes []Pass) (removed int) {
+	var before = program.Size()
+
+	for _, pass := range passes {
+		pass(program)
+	}
+
+	return before - program.Size()
+}
@@ -0,0 +1,136 @@
+package optimizer
+
+import (
+	"conveycode/compiler/ir"
+	"slices"
+)
+
+// The condition of a jump that holds exactly when the given one does not.
+// strictEqual has no opposite in mlog, so those jumps are left as they are
+var inverseConditions = map[string]string{
+	"equal":         "notEqual",
+	"notEqual":      "equal",
+	"lessThan":      "greaterThanEq",
+	"greaterThanEq": "lessThan",
+	"lessThanEq":    "greaterThan",
+	"greaterThan":   "lessThanEq",
+}
+
+// Clean up the jumps of the program in the order it is emitted, repeated until nothing changes
+//
+//	jump A always   // jump B always, when A is jump B always
+//	jump A equal x 1
+//	jump B always   // jump B notEqual x 1, when A is the next instruction
+//	jump C always   // removed, when C is the next instruction
+//
+// The processor goes back to the first instruction after the last one,
+// so a jump to the first instruction at the end of the program is removed as well.
+// A jump table is a single instruction here, so its slots are never removed or moved apart.
+// Code that no jump reaches anymore is removed along the way
+func peephole(program *ir.Program) {
+	var code = program.Code()
+
+	for changed := true; changed; {
+		changed = false
+		var positions = positionsOf(code)
+
+		for i, instruction := range code {
+			if instruction.Op != "jump" && instruction.Op != ir.TableOp {
+				continue
+			}
+
+			for a, arg := range instruction.Args {
+				if arg.Kind != ir.Label || arg.Name == "" {
+					continue
+				}
+
+				if target := thread(code, positions, arg.Name); target != arg.Name {
+					code[i].Args = slices.Clone(code[i].Args)
+					code[i].Args[a].Name = target
+					changed = true
+				}
+			}
+		}
+
+		for i := 0; i < len(code) && !changed; i++ {
+			var instruction = code[i]
+			if instruction.Op != "jump" {
+				continue
+			}
+
+			var next = nextInstruction(code, i)
+			if positions[instruction.Args[0].Name] == next {
+				code = slices.Delete(code, i, i+1)
+				changed = true
+				continue
+			}
+
+			var inverse, ok = inverseConditions[instruction.Args[1].Name]
+			if !ok || next != i+1 || !isAlways(code[next]) {
+				continue
+			}
+
+			if positions[instruction.Args[0].Name] == nextInstruction(code, next) {
+				var inverted = code[next]
+				inverted.Args = slices.Concat([]ir.Value{code[next].Args[0], {Kind: ir.Word, Name: inverse}}, instruction.Args[2:])
+				code = slices.Replace(code, i, i+2, inverted)
+				changed = true
+			}
+		}
+
+		if !changed {
+			//? Removing the blocks no jump reaches anymore can leave a jump right in front of where it goes
+			*program = *ir.Build(code)
+			var before = program.Size()
+			removeUnreachable(program)
+			code = program.Code()
+			changed = program.Size() != before
+		}
+	}
+}
+
+// Returns the label a jump to the label ends up at, following jumps that always jump
+func thread(code []ir.Instruction, positions map[string]int, label string) string {
+	var seen = map[string]bool{}
+
+	for !seen[label] {
+		seen[label] = true
+
+		var position, ok = positions[label]
+		if !ok || position < 0 || !isAlways(code[position]) {
+			break
+		}
+		label = code[position].Args[0].Name
+	}
+
+	return label
+}
+
+// Returns the index in code of the instruction each label points to.
+// A label at the end points to the first instruction, since the processor wraps around
+func positionsOf(code []ir.Instruction) map[string]int {
+	var positions = map[string]int{}
+	for i, instruction := range code {
+		if instruction.Op == ir.LabelOp {
+			positions[instruction.Args[0].Name] = nextInstruction(code, i)
+		}
+	}
+
+	return positions
+}
+
+// Returns the index of the instruction that runs after code[i] when it does not jump,
+// -1 when the program has no instructions
+func nextInstruction(code []ir.Instruction, i int) int {
+	for j := i + 1; j < len(code); j++ {
+		if code[j].Op != ir.LabelOp {
+			return j
+		}
+	}
+
+	return slices.IndexFunc(code, func(instruction ir.Instruction) bool { return instruction.Op != ir.LabelOp })
+}
+
+func isAlways(instruction ir.Instruction) bool {
+	return instruction.Op == "jump" && !instruction.IsConditional()
+}
@@ -0,0 +1,127 @@
+package optimizer
+
+import (
+	"conveycode/compiler/ir"
+	"maps"
+)
+
+// The value each variable is known to hold, either a literal or another variable it is a copy of
+type facts map[string]ir.Value
+
+// Returns the facts that hold in both a and b
+func meet(a facts, b facts) facts {
+	var result = facts{}
+	for name, value := range a {
+		if other, ok := b[name]; ok && other == value {
+			result[name] = value
+		}
+	}
+
+	return result
+}
+
+// Replace variables by the constants and variables they are known to hold, fold ops on constants
+// and remove sets that do not change their variable
+//
+//	var x = 3
+//	var z = x + 5 // set z 8
+//
+// The facts flow along the control-flow graph, a block only knows what holds on every path into it.
+// Nothing is known at the first block, since the processor starts there with every variable null
+// and also starts over there after the last instruction
+func propagate(program *ir.Program) {
+	if len(program.Blocks) == 0 {
+		return
+	}
+
+	var in = map[*ir.Block]facts{}
+	var out = map[*ir.Block]facts{}
+
+	for changed := true; changed; {
+		changed = false
+
+		for i, block := range program.Blocks {
+			var known facts
+			if i > 0 {
+				for _, pred := range block.Preds {
+					if predOut, ok := out[pred]; !ok {
+						continue
+					} else if known == nil {
+						known = maps.Clone(predOut)
+					} else {
+						known = meet(known, predOut)
+					}
+				}
+			}
+
+			if known == nil {
+				known = facts{}
+			}
+
+			in[block] = known
+			var result = maps.Clone(known)
+			for _, instruction := range block.Instructions {
+				transfer(result, instruction)
+			}
+
+			if previous, ok := out[block]; !ok || !maps.Equal(previous, result) {
+				out[block] = result
+				changed = true
+			}
+		}
+	}
+
+	for _, block := range program.Blocks {
+		var known = in[block]
+		var instructions []ir.Instruction
+
+		for _, instruction := range block.Instructions {
+			if instruction, keep := transfer(known, instruction); keep {
+				instructions = append(instructions, instruction)
+			}
+		}
+
+		block.Instructions = instructions
+	}
+}
+
+// Rewrite the instruction with the known facts and update them with what the instruction writes,
+// false when the instruction does nothing and can be removed
+func transfer(known facts, instruction ir.Instruction) (ir.Instruction, bool) {
+	//? sync sends the variable by its name, so it has to stay a variable
+	if instruction.Op != "sync" {
+		instruction = instruction.ReplaceUses(func(variable ir.Value) ir.Value {
+			if value, ok := known[variable.Name]; ok {
+				return value
+			}
+			return variable
+		})
+	}
+
+	instruction, _ = fold(instruction)
+
+	if instruction.Op == "set" {
+		var result, value = instruction.Args[0], instruction.Args[1]
+		if result == value || known[result.Name] == value {
+			return instruction, false
+		}
+	}
+
+	for _, def := range instruction.Defs() {
+		delete(known, def.Name)
+		for name, value := range known {
+			if value.Name == def.Name {
+				delete(known, name)
+			}
+		}
+	}
+
+	if instruction.Op == "set" {
+		var result, value = instruction.Args[0], instruction.Args[1]
+		if result.IsVariable() && !result.IsBuiltin() && (value.Kind == ir.Literal || value.IsVariable() && !value.IsBuiltin()) {
+			known[result.Name] = value
+		}
+	}
+
+	return instruction, true
+}
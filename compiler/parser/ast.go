@@ -0,0 +1,284 @@
+package parser
+
+import (
+	"conveycode/compiler/types"
+	"regexp"
+	"strings"
+)
+
+type Node interface {
+	Position() types.Position
+}
+
+type Expr interface {
+	Node
+	expr()
+}
+
+type Stmt interface {
+	Node
+	stmt()
+}
+
+// Holds the position of a node, embedded in every node type
+type node struct {
+	Pos types.Position
+}
+
+func (this node) Position() types.Position {
+	return this.Pos
+}
+
+//#region Expressions
+
+// A variable name
+type Ident struct {
+	node
+	Name string
+}
+
+// A built-in variable or constant of the game, the name includes the @
+//
+//	@unit
+//	@phase-fabric
+type Builtin struct {
+	node
+	Name string
+}
+
+// One of the constant keywords true, false or null
+type Constant struct {
+	node
+	Value string
+}
+
+type Number struct {
+	node
+	Value string
+}
+
+// A color literal, Value holds the lowercase hexadecimal digits without the #
+//
+//	#ff8800
+//	#ff8800cc
+type Color struct {
+	node
+	Value string
+}
+
+// A string literal, Value does not include the quotes
+type String struct {
+	node
+	Value string
+}
+
+// Access to a property of an object
+//
+//	container1.@copper
+//	turret.ammo
+type Member struct {
+	node
+	Object   Expr
+	Property string
+}
+
+type Call struct {
+	node
+	Callee Expr
+
+	// The positional arguments, followed by the arguments that are passed by name
+	Args  []Expr
+	Named []NamedArg
+}
+
+// radar(turret, enemy, sort: distance)
+type NamedArg struct {
+	node
+	Name  string
+	Value Expr
+}
+
+type Binary struct {
+	node
+	Op    string
+	Left  Expr
+	Right Expr
+}
+
+type Unary struct {
+	node
+	Op      string
+	Operand Expr
+}
+
+// Picks one of two values depending on the condition
+//
+//	hp < 50 ? "low" : "ok"
+type Conditional struct {
+	node
+	Cond Expr
+	Then Expr
+	Else Expr
+}
+
+func (*Ident) expr()       {}
+func (*Builtin) expr()     {}
+func (*Constant) expr()    {}
+func (*Number) expr()      {}
+func (*Color) expr()       {}
+func (*String) expr()      {}
+func (*Member) expr()      {}
+func (*Call) expr()        {}
+func (*Binary) expr()      {}
+func (*Unary) expr()       {}
+func (*Conditional) expr() {}
+
+//#endregion
+
+//#region Statements
+
+// Declaration of new variables, multiple names take the values a function returns
+//
+//	var x = 10
+//	var found, x, y, core = locate.building(core, ally)
+type VarDecl struct {
+	node
+	Names []string
+	Value Expr
+}
+
+// Assignment to an already existing variable
+//
+//	x = 10
+type Assign struct {
+	node
+	Target Expr
+	Value  Expr
+}
+
+// Declares the buildings that are linked to the processor,
+// Names holds the name used in the code for each building in Buildings
+//
+//	use cell1, message1
+//	link display = display1
+type Link struct {
+	node
+	Names     []string
+	Buildings []string
+}
+
+type If struct {
+	node
+	Cond Expr
+	Then *Block
+
+	// Either nil, a *Block or an *If for else if chains
+	Else Stmt
+}
+
+// Loops over a collection, the only collection that exists is links
+//
+//	for building in links { }
+type ForIn struct {
+	node
+	Name       string
+	Collection Expr
+	Body       *Block
+}
+
+// Runs the case that matches the value, or the default when none does. Cases do not fall through
+//
+//	switch state {
+//	case 0:
+//	    unit.move(x, y)
+//	case 1, 2:
+//	    unit.idle()
+//	default:
+//	    state = 0
+//	}
+type Switch struct {
+	node
+	Value Expr
+	Cases []*Case
+
+	// nil when the switch has no default case
+	Default *Block
+}
+
+// The values of a case are constants: numbers, strings, content or the constant keywords
+type Case struct {
+	node
+	Values []Expr
+	Body   *Block
+}
+
+type Break struct {
+	node
+}
+
+type Continue struct {
+	node
+}
+
+// Raw mlog instructions, one per line. {name} is replaced with the variable
+// and the labels are renamed so they do not clash with the rest of the program
+//
+//	asm {
+//	    sensor {hp} {target} @health
+//	}
+type Asm struct {
+	node
+	Lines []AsmLine
+}
+
+type AsmLine struct {
+	node
+	Text string
+}
+
+// Matches the {name} placeholders in an asm line
+var AsmPlaceholder = regexp.MustCompile(`\{(\w+)\}`)
+
+// Returns the name of the label when the line declares one
+//
+//	loop:
+func (this AsmLine) Label() (string, bool) {
+	if strings.HasSuffix(this.Text, ":") && !strings.ContainsAny(this.Text, " \t") {
+		return strings.TrimSuffix(this.Text, ":"), true
+	}
+
+	return "", false
+}
+
+// An expression that is used as a statement, such as a function call
+type ExprStmt struct {
+	node
+	Expr Expr
+}
+
+type Block struct {
+	node
+	Stmts []Stmt
+}
+
+func (*VarDecl) stmt()  {}
+func (*Assign) stmt()   {}
+func (*Link) stmt()     {}
+func (*If) stmt()       {}
+func (*ForIn) stmt()    {}
+func (*Switch) stmt()   {}
+func (*Break) stmt()    {}
+func (*Continue) stmt() {}
+func (*Asm) stmt()      {}
+func (*ExprStmt) stmt() {}
+func (*Block) stmt()    {}
+
+//#endregion
+
+type Program struct {
+	Stmts []Stmt
+}
+
+func (*Program) Position() types.Position {
+	return types.Position{Line: 1, Column: 1}
+}
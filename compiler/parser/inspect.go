@@ -0,0 +1,66 @@
+package parser
+
+// Walks the tree in depth first order, calling f for each node.
+// The children of a node are skipped when f returns false
+func Inspect(node Node, f func(Node) bool) {
+	if node == nil || !f(node) {
+		return
+	}
+
+	switch node := node.(type) {
+	case *Program:
+		for _, stmt := range node.Stmts {
+			Inspect(stmt, f)
+		}
+	case *Block:
+		for _, stmt := range node.Stmts {
+			Inspect(stmt, f)
+		}
+	case *VarDecl:
+		Inspect(node.Value, f)
+	case *Assign:
+		Inspect(node.Target, f)
+		Inspect(node.Value, f)
+	case *If:
+		Inspect(node.Cond, f)
+		Inspect(node.Then, f)
+		if node.Else != nil {
+			Inspect(node.Else, f)
+		}
+	case *ForIn:
+		Inspect(node.Collection, f)
+		Inspect(node.Body, f)
+	case *Switch:
+		Inspect(node.Value, f)
+		for _, c := range node.Cases {
+			for _, value := range c.Values {
+				Inspect(value, f)
+			}
+			Inspect(c.Body, f)
+		}
+		if node.Default != nil {
+			Inspect(node.Default, f)
+		}
+	case *ExprStmt:
+		Inspect(node.Expr, f)
+	case *Member:
+		Inspect(node.Object, f)
+	case *Call:
+		Inspect(node.Callee, f)
+		for _, arg := range node.Args {
+			Inspect(arg, f)
+		}
+		for _, arg := range node.Named {
+			Inspect(arg.Value, f)
+		}
+	case *Binary:
+		Inspect(node.Left, f)
+		Inspect(node.Right, f)
+	case *Unary:
+		Inspect(node.Operand, f)
+	case *Conditional:
+		Inspect(node.Cond, f)
+		Inspect(node.Then, f)
+		Inspect(node.Else, f)
+	}
+}
@@ -1,33 +1,634 @@
-package compiler
+package parser
 
 import (
-	"conveycode/compiler/constructor"
+	"conveycode/compiler/diagnostics"
+	"conveycode/compiler/tokenizer"
+	"conveycode/compiler/types"
+	"regexp"
 	"slices"
 	"strings"
 )
 
-// import "fmt"
+// The binding power of each binary operator, higher binds tighter
+var binaryPrecedence = map[string]int{
+	"||": 1,
+	"&&": 2,
+	"|":  3,
+	"&":  4,
+	"==": 5, "!=": 5, "===": 5,
+	"<": 6, "<=": 6, ">": 6, ">=": 6,
+	"<<": 7, ">>": 7,
+	"+": 8, "-": 8,
+	"*": 9, "/": 9, "//": 9, "%": 9,
+	"**": 10,
+}
+
+// Binary operators that group from right to left
+//
+//	2 ** 3 ** 2 // 2 ** (3 ** 2)
+var rightAssociative []string = []string{"**"}
+
+// Keywords that are values
+var constantKeywords []string = []string{"true", "false", "null"}
+
+var unaryOperators []string = []string{"!", "-", "+"}
+
+// A color literal with or without the alpha channel
+var colorPattern = regexp.MustCompile(`^#([0-9a-fA-F]{6}|[0-9a-fA-F]{8})$`)
+
+// Assignment operators that combine the current value with the new one
+//
+//	x += 2 // x = x + 2
+var compoundAssignments = map[string]string{
+	"+=": "+",
+	"-=": "-",
+	"*=": "*",
+	"/=": "/",
+}
+
+// Operators that are written after a variable to change it by one
+//
+//	x++ // x = x + 1
+var stepOperators = map[string]string{
+	"++": "+",
+	"--": "-",
+}
+
+type parser struct {
+	tokens tokenizer.TokenList
+	pos    int
+	diags  *diagnostics.List
+}
+
+// Used to unwind the parser back to the statement level after an error was reported
+type bailout struct{}
+
+// Parse the tokens into a program, any syntax errors are added to diags
+func Parse(tokens tokenizer.TokenList, diags *diagnostics.List) *Program {
+	var this = &parser{
+		tokens: slices.DeleteFunc(slices.Clone(tokens), func(t tokenizer.Token) bool { return t.Typ == tokenizer.Comment }),
+		diags:  diags,
+	}
 
-var variables []string
+	var program = &Program{}
 
-func ParseSegments(tokens []string) string {
-	var parts []string
+	for this.skipEOL(); !this.is(tokenizer.EOF); this.skipEOL() {
+		//? synchronize stops in front of a }, at the top level there is no block for it to close
+		if this.is(tokenizer.CurlyR) {
+			this.diags.Errorf(this.next().Pos, "unexpected }, there is no block to close")
+			continue
+		}
 
-	for _, seg := range tokens {
-		parts = append(parts, string(seg))
+		if stmt := this.statement(); stmt != nil {
+			program.Stmts = append(program.Stmts, stmt)
+		}
 	}
 
-	if string(tokens[0]) == "var" {
-		if !slices.Contains(variables, string(tokens[1])) {
-			variables = append(variables, string(tokens[1]))
+	return program
+}
+
+//#region Statements
+
+// Parses a single statement, on a syntax error it skips to the next line and returns nil
+func (this *parser) statement() (stmt Stmt) {
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(bailout); !ok {
+				panic(r)
+			}
+
+			this.synchronize()
+			stmt = nil
 		}
-		return constructor.Assignment(parts)
-	} else if slices.Contains(variables, parts[0]) {
-		return constructor.Assignment(parts)
+	}()
+
+	switch {
+	case this.isKeyword("var"):
+		stmt = this.varDecl()
+	case this.isKeyword("link"):
+		stmt = this.link()
+	case this.isKeyword("use"):
+		stmt = this.use()
+	case this.isKeyword("if"):
+		stmt = this.ifStatement()
+	case this.isKeyword("for"):
+		stmt = this.forIn()
+	case this.isKeyword("switch"):
+		stmt = this.switchStatement()
+	case this.is(tokenizer.Asm):
+		stmt = this.asm()
+	case this.isKeyword("break"):
+		stmt = &Break{node: at(this.next())}
+	case this.isKeyword("continue"):
+		stmt = &Continue{node: at(this.next())}
+	case this.is(tokenizer.Keyword) && !slices.Contains(constantKeywords, string(this.token().Val)):
+		this.errorf("unexpected keyword %s", string(this.token().Val))
+	default:
+		stmt = this.simpleStatement()
 	}
 
-	return ("# ERROR: " + strings.Join(parts, ""))
+	this.endOfStatement()
+	return stmt
+}
+
+// Skip to the end of the statement that failed to parse, including any block it opened
+func (this *parser) synchronize() {
+	var depth = 0
 
-	// for _, seg := range segments {
-	// }
+	for !this.is(tokenizer.EOF) {
+		switch this.token().Typ {
+		case tokenizer.EOL:
+			if depth == 0 {
+				return
+			}
+		case tokenizer.CurlyL:
+			depth++
+		case tokenizer.CurlyR:
+			//? The closing bracket of the enclosing block
+			if depth == 0 {
+				return
+			}
+			depth--
+		}
+
+		this.next()
+	}
 }
+
+func (this *parser) varDecl() Stmt {
+	var start = this.next()
+	var stmt = &VarDecl{node: at(start)}
+
+	for {
+		stmt.Names = append(stmt.Names, string(this.name("variable name").Val))
+
+		if !this.is(tokenizer.Seperator) {
+			break
+		}
+		this.next()
+	}
+
+	this.expectOperator("=")
+
+	stmt.Value = this.expression(0)
+	return stmt
+}
+
+// link display = display1
+func (this *parser) link() Stmt {
+	var start = this.next()
+	var name = this.name("building name")
+
+	this.expectOperator("=")
+
+	var building = this.name("building link")
+	return &Link{node: at(start), Names: []string{string(name.Val)}, Buildings: []string{string(building.Val)}}
+}
+
+// use cell1, message1
+func (this *parser) use() Stmt {
+	var start = this.next()
+	var stmt = &Link{node: at(start)}
+
+	for {
+		var building = string(this.name("building link").Val)
+		stmt.Names = append(stmt.Names, building)
+		stmt.Buildings = append(stmt.Buildings, building)
+
+		if !this.is(tokenizer.Seperator) {
+			return stmt
+		}
+		this.next()
+	}
+}
+
+func (this *parser) ifStatement() Stmt {
+	var start = this.next()
+	var stmt = &If{node: at(start), Cond: this.expression(0)}
+
+	stmt.Then = this.block()
+
+	//? The else keyword is allowed to be on the next line after the closing bracket
+	var save = this.pos
+	this.skipEOL()
+
+	if !this.isKeyword("else") {
+		this.pos = save
+		return stmt
+	}
+
+	this.next()
+	if this.isKeyword("if") {
+		stmt.Else = this.ifStatement()
+	} else {
+		stmt.Else = this.block()
+	}
+
+	return stmt
+}
+
+func (this *parser) forIn() Stmt {
+	var start = this.next()
+	var name = this.name("loop variable")
+
+	if !this.isKeyword("in") {
+		this.errorf("unexpected \"%s\", expected in", describe(this.token()))
+	}
+	this.next()
+
+	return &ForIn{node: at(start), Name: string(name.Val), Collection: this.expression(0), Body: this.block()}
+}
+
+func (this *parser) switchStatement() Stmt {
+	var start = this.next()
+	var stmt = &Switch{node: at(start), Value: this.expression(0)}
+	var open = this.expect(tokenizer.CurlyL, "{")
+
+	for this.skipEOL(); !this.is(tokenizer.CurlyR); this.skipEOL() {
+		var label = this.token()
+
+		switch {
+		case this.is(tokenizer.EOF):
+			this.errorf("expected } to close the switch opened at %s", open.Pos)
+		case this.isKeyword("case"):
+			this.next()
+			var c = &Case{node: at(label)}
+
+			for {
+				c.Values = append(c.Values, this.expression(0))
+
+				if !this.is(tokenizer.Seperator) {
+					break
+				}
+				this.next()
+			}
+
+			this.expect(tokenizer.Colon, ":")
+			c.Body = this.caseBody(label)
+			stmt.Cases = append(stmt.Cases, c)
+		case this.isKeyword("default"):
+			this.next()
+			this.expect(tokenizer.Colon, ":")
+
+			if stmt.Default != nil {
+				this.diags.Errorf(label.Pos, "a switch can only have one default")
+			}
+			stmt.Default = this.caseBody(label)
+		default:
+			this.errorf("unexpected \"%s\", expected case or default", describe(label))
+		}
+	}
+
+	this.next()
+	return stmt
+}
+
+// The statements of a case, up to the next case or the end of the switch
+func (this *parser) caseBody(start tokenizer.Token) *Block {
+	var block = &Block{node: at(start)}
+
+	for this.skipEOL(); !this.isKeyword("case") && !this.isKeyword("default") && !this.is(tokenizer.CurlyR); this.skipEOL() {
+		if this.is(tokenizer.EOF) {
+			this.errorf("expected } to close the switch")
+		}
+
+		if stmt := this.statement(); stmt != nil {
+			block.Stmts = append(block.Stmts, stmt)
+		}
+	}
+
+	return block
+}
+
+// asm { ... }, the tokenizer keeps the block as one token with the brackets
+func (this *parser) asm() Stmt {
+	var token = this.next()
+	var body = string(token.Val)
+
+	if len(body) < 2 || !strings.HasSuffix(body, "}") {
+		this.diags.Errorf(token.Pos, "expected } to close the asm block")
+	}
+	body = strings.TrimSuffix(strings.TrimPrefix(body, "{"), "}")
+
+	var stmt = &Asm{node: at(token)}
+	for i, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+
+		//? mlog comments start with #
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var pos = types.Position{Line: token.Pos.Line + i, Column: 1}
+		if i == 0 {
+			pos.Column = token.Pos.Column
+		}
+
+		stmt.Lines = append(stmt.Lines, AsmLine{node: node{Pos: pos}, Text: line})
+	}
+
+	return stmt
+}
+
+func (this *parser) block() *Block {
+	var start = this.expect(tokenizer.CurlyL, "{")
+	var block = &Block{node: at(start)}
+
+	for this.skipEOL(); !this.is(tokenizer.CurlyR); this.skipEOL() {
+		if this.is(tokenizer.EOF) {
+			this.errorf("expected } to close the block opened at %s", start.Pos)
+		}
+
+		if stmt := this.statement(); stmt != nil {
+			block.Stmts = append(block.Stmts, stmt)
+		}
+	}
+
+	this.next()
+	return block
+}
+
+// An assignment or an expression statement
+func (this *parser) simpleStatement() Stmt {
+	var start = this.token()
+	var expr = this.expression(0)
+
+	var op = this.operator()
+
+	if op == "=" {
+		this.next()
+		return &Assign{node: at(start), Target: expr, Value: this.expression(0)}
+	}
+
+	if binary, ok := compoundAssignments[op]; ok {
+		var opToken = this.next()
+		var value = &Binary{node: at(opToken), Op: binary, Left: expr, Right: this.expression(0)}
+		return &Assign{node: at(start), Target: expr, Value: value}
+	}
+
+	if binary, ok := stepOperators[op]; ok {
+		var opToken = this.next()
+		var value = &Binary{node: at(opToken), Op: binary, Left: expr, Right: &Number{node: at(opToken), Value: "1"}}
+		return &Assign{node: at(start), Target: expr, Value: value}
+	}
+
+	if _, ok := expr.(*Call); !ok {
+		this.diags.Errorf(start.Pos, "expression is not used, expected an assignment or a function call")
+	}
+
+	return &ExprStmt{node: at(start), Expr: expr}
+}
+
+func (this *parser) endOfStatement() {
+	switch this.token().Typ {
+	case tokenizer.EOL:
+		this.next()
+	case tokenizer.EOF, tokenizer.CurlyR:
+	default:
+		this.errorf("unexpected \"%s\", expected the end of the line", describe(this.token()))
+	}
+}
+
+//#endregion
+
+//#region Expressions
+
+// Parses an expression where every binary operator binds at least as tight as minPrecedence
+func (this *parser) expression(minPrecedence int) Expr {
+	var left = this.unary()
+
+	for {
+		var op = this.operator()
+		var precedence, ok = binaryPrecedence[op]
+
+		//? The conditional operator binds the loosest of all, so it only continues a whole expression
+		if op == "?" && minPrecedence == 0 {
+			return this.conditional(left)
+		}
+
+		if !ok || precedence < minPrecedence {
+			return left
+		}
+
+		var start = this.next()
+		this.skipEOL()
+
+		var next = precedence + 1
+		if slices.Contains(rightAssociative, op) {
+			next = precedence
+		}
+
+		left = &Binary{node: at(start), Op: op, Left: left, Right: this.expression(next)}
+	}
+}
+
+// cond ? then : else, where else can be another conditional
+func (this *parser) conditional(cond Expr) Expr {
+	var start = this.next()
+	this.skipEOL()
+
+	var then = this.expression(0)
+	this.skipEOL()
+	this.expect(tokenizer.Colon, ":")
+	this.skipEOL()
+
+	return &Conditional{node: at(start), Cond: cond, Then: then, Else: this.expression(0)}
+}
+
+func (this *parser) unary() Expr {
+	if op := this.operator(); slices.Contains(unaryOperators, op) {
+		var start = this.next()
+
+		//? Only ** binds tighter than a unary operator, so -2 ** 2 is -(2 ** 2)
+		return &Unary{node: at(start), Op: op, Operand: this.expression(binaryPrecedence["**"])}
+	}
+
+	return this.postfix(this.primary())
+}
+
+// Member access and function calls
+func (this *parser) postfix(expr Expr) Expr {
+	for {
+		if this.is(tokenizer.Dot) {
+			var start = this.next()
+			if !this.is(tokenizer.Ident) && !this.is(tokenizer.Builtin) {
+				this.errorf("unexpected \"%s\", expected property name", describe(this.token()))
+			}
+
+			expr = &Member{node: at(start), Object: expr, Property: string(this.next().Val)}
+			continue
+		}
+
+		if this.is(tokenizer.RoundL) {
+			var start = this.next()
+			var call = &Call{node: at(start), Callee: expr}
+			this.arguments(call)
+			expr = call
+			continue
+		}
+
+		return expr
+	}
+}
+
+// Parses a comma seperated list of arguments up to and including the closing bracket
+//
+// Named arguments have to come after the positional ones
+func (this *parser) arguments(call *Call) {
+	for this.skipEOL(); !this.is(tokenizer.RoundR); this.skipEOL() {
+		var isNamed = this.is(tokenizer.Ident) && this.pos+1 < len(this.tokens) && this.tokens[this.pos+1].Typ == tokenizer.Colon
+
+		if isNamed {
+			var name = this.next()
+			this.next()
+			this.skipEOL()
+			call.Named = append(call.Named, NamedArg{node: at(name), Name: string(name.Val), Value: this.expression(0)})
+		} else {
+			if len(call.Named) > 0 {
+				this.errorf("positional arguments have to come before named arguments")
+			}
+
+			call.Args = append(call.Args, this.expression(0))
+		}
+
+		this.skipEOL()
+		if !this.is(tokenizer.Seperator) {
+			break
+		}
+		this.next()
+	}
+
+	this.expect(tokenizer.RoundR, ")")
+}
+
+func (this *parser) primary() Expr {
+	var token = this.token()
+
+	switch token.Typ {
+	case tokenizer.Number:
+		this.next()
+		return &Number{node: at(token), Value: string(token.Val)}
+	case tokenizer.Color:
+		this.next()
+		if !colorPattern.MatchString(string(token.Val)) {
+			this.diags.Errorf(token.Pos, "%s is not a color, colors are written as #rrggbb or #rrggbbaa", string(token.Val))
+		}
+		return &Color{node: at(token), Value: strings.ToLower(string(token.Val[1:]))}
+	case tokenizer.String:
+		this.next()
+		return &String{node: at(token), Value: string(token.Val[1 : len(token.Val)-1])}
+	case tokenizer.Ident:
+		this.next()
+		return &Ident{node: at(token), Name: string(token.Val)}
+	case tokenizer.Keyword:
+		if slices.Contains(constantKeywords, string(token.Val)) {
+			this.next()
+			return &Constant{node: at(token), Value: string(token.Val)}
+		}
+
+		this.errorf("%s is a reserved word and can not be used as a name", string(token.Val))
+	case tokenizer.Builtin:
+		this.next()
+		return &Builtin{node: at(token), Name: string(token.Val)}
+	case tokenizer.RoundL:
+		this.next()
+		this.skipEOL()
+		var expr = this.expression(0)
+		this.skipEOL()
+		this.expect(tokenizer.RoundR, ")")
+		return expr
+	}
+
+	this.errorf("unexpected \"%s\", expected an expression", describe(token))
+	return nil
+}
+
+//#endregion
+
+//#region Utilities
+
+func at(token tokenizer.Token) node {
+	return node{Pos: token.Pos}
+}
+
+// Describe the token for use in an error message
+func describe(token tokenizer.Token) string {
+	switch token.Typ {
+	case tokenizer.EOL:
+		return "end of line"
+	case tokenizer.EOF:
+		return "end of file"
+	}
+
+	return string(token.Val)
+}
+
+func (this *parser) token() tokenizer.Token {
+	return this.tokens[this.pos]
+}
+
+func (this *parser) next() (ret tokenizer.Token) {
+	ret = this.token()
+
+	if ret.Typ != tokenizer.EOF {
+		this.pos++
+	}
+
+	return
+}
+
+func (this *parser) is(typ tokenizer.TokenType) bool {
+	return this.token().Typ == typ
+}
+
+func (this *parser) isKeyword(word string) bool {
+	return this.is(tokenizer.Keyword) && string(this.token().Val) == word
+}
+
+func (this *parser) skipEOL() {
+	for this.is(tokenizer.EOL) {
+		this.next()
+	}
+}
+
+// Returns the operator at the current position, or an empty string if it is not an operator
+func (this *parser) operator() string {
+	if !this.is(tokenizer.Operator) {
+		return ""
+	}
+
+	return string(this.token().Val)
+}
+
+func (this *parser) expect(typ tokenizer.TokenType, what string) tokenizer.Token {
+	if !this.is(typ) {
+		this.errorf("unexpected \"%s\", expected %s", describe(this.token()), what)
+	}
+
+	return this.next()
+}
+
+// Expect an identifier, reserved words are reported as such
+func (this *parser) name(what string) tokenizer.Token {
+	if this.is(tokenizer.Keyword) {
+		this.errorf("%s is a reserved word and can not be used as a %s", string(this.token().Val), what)
+	}
+
+	return this.expect(tokenizer.Ident, what)
+}
+
+func (this *parser) expectOperator(op string) {
+	if this.operator() != op {
+		this.errorf("unexpected \"%s\", expected %s", describe(this.token()), op)
+	}
+
+	this.next()
+}
+
+// Report an error at the current token and unwind to the statement level
+func (this *parser) errorf(format string, args ...any) {
+	this.diags.Errorf(this.token().Pos, format, args...)
+	panic(bailout{})
+}
+
+//#endregion
@@ -0,0 +1,127 @@
+package compiler
+
+import (
+	"cmp"
+	"conveycode/compiler/diagnostics"
+	"conveycode/compiler/ir"
+	"conveycode/compiler/mindustry"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// The number of instructions each part of the source compiled to
+type SizeReport struct {
+	Total int
+
+	// Keyed by the built-in function, instructions that are not part of a call are under ""
+	Functions map[string]int
+
+	Lines      map[int]int
+	Constructs map[string]int
+}
+
+// Count the instructions of the program by where they come from
+func measure(program *ir.Program) SizeReport {
+	var report = SizeReport{Functions: map[string]int{}, Lines: map[int]int{}, Constructs: map[string]int{}}
+
+	for _, block := range program.Blocks {
+		for _, instruction := range block.Instructions {
+			var size = instruction.Size()
+			report.Total += size
+			report.Functions[instruction.Origin.Function] += size
+			report.Lines[instruction.Origin.Pos.Line] += size
+			report.Constructs[instruction.Origin.Construct] += size
+		}
+	}
+
+	return report
+}
+
+// Report an error when the program has more instructions than a processor accepts,
+// pointing at the statement of the first instruction that does not fit
+//
+//	the program has 1240 instructions but a processor only accepts 1000, the largest lines are line 12 (400), ...
+func checkSize(program *ir.Program, report SizeReport, diags *diagnostics.List) {
+	if report.Total <= mindustry.MaxInstructions {
+		return
+	}
+
+	var count = 0
+	var overflow ir.Origin
+
+	for _, block := range program.Blocks {
+		for _, instruction := range block.Instructions {
+			if count <= mindustry.MaxInstructions {
+				overflow = instruction.Origin
+			}
+			count += instruction.Size()
+		}
+	}
+
+	var message = fmt.Sprintf("the program has %d instructions but a processor only accepts %d, the largest lines are %s",
+		report.Total, mindustry.MaxInstructions,
+		describe(largest(report.Lines, 3), report.Lines, func(line int) string { return fmt.Sprintf("line %d", line) }))
+
+	//? Instructions outside of calls are already counted by their lines
+	var calls = maps.Clone(report.Functions)
+	delete(calls, "")
+	if len(calls) > 0 {
+		message += ", the largest functions are " + describe(largest(calls, 3), calls, func(name string) string { return name })
+	}
+
+	diags.Errorf(overflow.Pos, "%s", message)
+}
+
+// Returns the table of the report that is printed with --size-report
+func (this SizeReport) String() string {
+	var builder strings.Builder
+
+	fmt.Fprintf(&builder, "%d instructions\n", this.Total)
+
+	builder.WriteString("\nBy function\n")
+	for _, name := range largest(this.Functions, len(this.Functions)) {
+		var label = name
+		if name == "" {
+			label = "(no function)"
+		}
+		fmt.Fprintf(&builder, "%6d  %s\n", this.Functions[name], label)
+	}
+
+	builder.WriteString("\nBy line\n")
+	for _, line := range largest(this.Lines, len(this.Lines)) {
+		fmt.Fprintf(&builder, "%6d  line %d\n", this.Lines[line], line)
+	}
+
+	builder.WriteString("\nBy construct\n")
+	for _, construct := range largest(this.Constructs, len(this.Constructs)) {
+		fmt.Fprintf(&builder, "%6d  %s\n", this.Constructs[construct], construct)
+	}
+
+	return builder.String()
+}
+
+// Returns at most count keys with the highest counts, the highest first
+func largest[K cmp.Ordered](counts map[K]int, count int) []K {
+	var keys = slices.SortedFunc(maps.Keys(counts), func(a K, b K) int {
+		if counts[a] != counts[b] {
+			return counts[b] - counts[a]
+		}
+		return cmp.Compare(a, b)
+	})
+
+	return keys[:min(count, len(keys))]
+}
+
+// Returns the keys with their counts for a message
+//
+//	describe(["print"], counts, name) // print (400)
+func describe[K comparable](keys []K, counts map[K]int, name func(K) string) string {
+	var parts []string
+	for _, key := range keys {
+		parts = append(parts, fmt.Sprintf("%s (%d)", name(key), counts[key]))
+	}
+
+	return strings.Join(parts, ", ")
+}
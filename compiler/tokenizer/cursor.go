@@ -75,6 +75,11 @@ func (cur *Cursor) String() string {
 	return fmt.Sprintf("Content Length: %d\nPosition: %d\nLine: %d\nEOF: %t", len(cur.Content), cur.Pos, cur.Line, cur.EOF)
 }
 
+// Returns the line and column the cursor is currently on
+func (cur *Cursor) Position() types.Position {
+	return types.Position{Line: cur.Line, Column: cur.Column}
+}
+
 // Seek the cursors position relative to its current position.
 //
 // If the offset is out of range, the cursors position will remain the same and the function returns false
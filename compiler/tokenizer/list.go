@@ -1,5 +1,7 @@
 package tokenizer
 
+import "conveycode/compiler/types"
+
 type TokenList []Token
 
 func NewTokenList() TokenList {
@@ -45,6 +47,13 @@ func (this *TokenList) Push(t TokenType, v ...rune) {
 	// fmt.Println(NewToken(t, v))
 }
 
+// Push a token that starts at the given position in the source
+func (this *TokenList) PushAt(pos types.Position, t TokenType, v ...rune) {
+	var token = NewToken(t, v)
+	token.Pos = pos
+	*this = append(*this, token)
+}
+
 func (this TokenList) Values() (ret [][]rune) {
 	ret = make([][]rune, len(this))
 	for i, token := range this {
@@ -1,6 +1,7 @@
 package tokenizer
 
 import (
+	"conveycode/compiler/types"
 	"fmt"
 
 	"github.com/TwiN/go-color"
@@ -15,7 +16,12 @@ const (
 	Comment
 	String
 	Number
+	Builtin
+	Color
+	Asm
 	Operator
+	Dot
+	Colon
 	Seperator
 
 	RoundL
@@ -25,7 +31,8 @@ const (
 	CurlyL
 	CurlyR
 
-	Text
+	Keyword
+	Ident
 	EOF
 )
 
@@ -35,7 +42,12 @@ func (this TokenType) String() string {
 		"Comment",
 		"String",
 		"Number",
+		"Builtin",
+		"Color",
+		"Asm",
 		"Operator",
+		"Dot",
+		"Colon",
 		"Seperator",
 
 		"RoundL",
@@ -45,7 +57,8 @@ func (this TokenType) String() string {
 		"CurlyL",
 		"CurlyR",
 
-		"Other",
+		"Keyword",
+		"Ident",
 		"EOF",
 	}[this-1]
 }
@@ -54,6 +67,9 @@ func (this TokenType) String() string {
 type Token struct {
 	Typ TokenType
 	Val []rune
+
+	// Where the token starts in the source file
+	Pos types.Position
 }
 
 func NewToken(t TokenType, v []rune) Token {
@@ -71,11 +87,15 @@ func (this Token) ColoredValue() string {
 	switch this.Typ {
 	case String:
 		return color.Colorize(color.Red, string(this.Val))
-	case Number:
+	case Number, Color:
 		return color.Colorize(color.Green, string(this.Val))
+	case Keyword:
+		return color.InBold(string(this.Val))
+	case Builtin:
+		return color.Colorize(color.Purple, string(this.Val))
 	case Operator:
 		return color.Colorize(color.Blue, string(this.Val))
-	case Seperator:
+	case Dot, Colon, Seperator:
 		return color.Colorize(color.Cyan, string(this.Val))
 	case RoundL, RoundR, SquareL, SquareR, CurlyL, CurlyR:
 		return color.Colorize(color.Yellow, string(this.Val))
@@ -1,6 +1,7 @@
 package tokenizer
 
 import (
+	"conveycode/compiler/utils"
 	"fmt"
 	"regexp"
 	"slices"
@@ -12,6 +13,24 @@ import (
 
 var regStream *regexp.Regexp
 
+// Words that have a meaning in the language and can not be used as names
+var Keywords []string = []string{
+	"var", "const", "link", "use",
+	"if", "else", "while", "for", "in", "switch", "case", "default",
+	"func", "return", "break", "continue", "asm",
+	"true", "false", "null",
+}
+
+// Every operator the tokenizer recognizes, the longest one that matches is used.
+//
+// "//" is not in here, Tokenize decides if it divides or starts a comment
+var operators []string = []string{
+	"===",
+	"==", "!=", "<=", ">=", "&&", "||", "**", "<<", ">>",
+	"+=", "-=", "*=", "/=", "++", "--", "->", "..",
+	"+", "-", "*", "/", "%", "=", ">", "<", "!", "&", "|", "?",
+}
+
 // #region Handlers
 type handler struct {
 	test   func(cursor *Cursor) bool
@@ -79,7 +98,8 @@ var handlers = handlerMap{
 	},
 	Number: {
 		test: func(cursor *Cursor) bool {
-			return unicode.IsDigit(cursor.Peek()) || (cursor.ContainsChar("+-") && unicode.IsDigit(cursor.PeekNext()))
+			//? A sign in front of a number is a unary operator, which the parser handles
+			return unicode.IsDigit(cursor.Peek())
 		},
 		handle: func(cursor *Cursor) (v []rune) {
 			var stream []rune
@@ -95,15 +115,11 @@ var handlers = handlerMap{
 				return stream
 			}
 
-			if cursor.ContainsChar("+-") {
-				stream = append(stream, cursor.Read())
-			}
-
 			var decimalPoint = false
 			var exponent = false
 			stream = append(stream, cursor.ReadUntilFunc(func(c rune) bool {
-				//? Accept floating point numbers
-				if c == '.' && !decimalPoint && !exponent {
+				//? Accept floating point numbers, a dot that is not followed by a digit is not part of the number
+				if c == '.' && !decimalPoint && !exponent && unicode.IsDigit(cursor.PeekNext()) {
 					decimalPoint = true
 					return false
 				}
@@ -124,8 +140,97 @@ var handlers = handlerMap{
 			return stream
 		},
 	},
+	Builtin: {
+		test: func(cursor *Cursor) bool {
+			return cursor.Peek() == '@' && regStream.MatchString(string(cursor.PeekNext()))
+		},
+		handle: func(cursor *Cursor) (v []rune) {
+			var stream = []rune{cursor.Read()}
+
+			//? Content names like @phase-fabric contain dashes, but only ever in between words
+			return append(stream, cursor.ReadUntilFunc(func(c rune) bool {
+				if c == '-' {
+					return !regStream.MatchString(string(cursor.PeekNext()))
+				}
+
+				return !regStream.MatchString(string(c))
+			})...)
+		},
+	},
+	Color: {
+		test: func(cursor *Cursor) bool {
+			return cursor.Peek() == '#' && regStream.MatchString(string(cursor.PeekNext()))
+		},
+		handle: func(cursor *Cursor) (v []rune) {
+			var stream = []rune{cursor.Read()}
+
+			//? Read the whole word so that the parser can report a color that is not valid hexadecimal
+			return append(stream, cursor.ReadUntilFunc(func(c rune) bool {
+				return !regStream.MatchString(string(c))
+			})...)
+		},
+	},
+	Asm: {
+		test: func(cursor *Cursor) bool {
+			if regStream.MatchString(string(cursor.PeekPrev())) {
+				return false
+			}
+
+			for i, char := range "asm" {
+				if cursor.PeekOffset(i) != char {
+					return false
+				}
+			}
+
+			var offset = 3
+			for cursor.PeekOffset(offset) == ' ' || cursor.PeekOffset(offset) == '\t' {
+				offset++
+			}
+
+			return cursor.PeekOffset(offset) == '{'
+		},
+		handle: func(cursor *Cursor) (v []rune) {
+			//? The mlog in the block is kept as it is, including the brackets so the parser can tell if it was closed
+			cursor.ReadUntilFunc(func(c rune) bool { return c == '{' })
+			var stream = []rune{cursor.Read()}
+
+			var depth = 0
+			var quoted = false
+			stream = append(stream, cursor.ReadUntilFunc(func(c rune) bool {
+				switch {
+				case c == '"':
+					quoted = !quoted
+				case quoted:
+				case c == '{':
+					depth++
+				case c == '}' && depth == 0:
+					return true
+				case c == '}':
+					depth--
+				}
+
+				return false
+			})...)
+
+			if !cursor.EOF {
+				stream = append(stream, cursor.Read())
+			}
+
+			return stream
+		},
+	},
 
-	Operator:  {test: nil, handle: nil, runes: []rune{'+', '-', '*', '/', '%', '=', '>', '<', '!', '&', '|'}},
+	Operator: {
+		test: func(cursor *Cursor) bool {
+			return matchOperator(cursor) != ""
+		},
+		handle: func(cursor *Cursor) (v []rune) {
+			return cursor.ReadN(len(matchOperator(cursor)))
+		},
+	},
+
+	Dot:       {test: nil, handle: nil, runes: []rune{'.'}},
+	Colon:     {test: nil, handle: nil, runes: []rune{':'}},
 	Seperator: {test: nil, handle: nil, runes: []rune{','}},
 	RoundL:    {test: nil, handle: nil, runes: []rune{'('}},
 	RoundR:    {test: nil, handle: nil, runes: []rune{')'}},
@@ -161,13 +266,21 @@ func Tokenize(content []rune) TokenList {
 	var cursor = NewCursor(content)
 
 	for !cursor.EOF {
+		var pos = cursor.Position()
+
+		//? After a value "//" is integer division, anywhere else it starts a comment
+		if cursor.Peek() == '/' && cursor.PeekNext() == '/' && endsWithOperand(tokens) {
+			tokens.PushAt(pos, Operator, cursor.ReadN(2)...)
+			continue
+		}
+
 		var handled = false
 		for _, typ := range handlerKeys {
 			hand := handlers[typ]
 
 			if hand.test == nil && hand.runes != nil {
 				if slices.Contains(hand.runes, cursor.Peek()) {
-					tokens.Push(typ, cursor.Read())
+					tokens.PushAt(pos, typ, cursor.Read())
 					handled = true
 					break
 				}
@@ -176,7 +289,7 @@ func Tokenize(content []rune) TokenList {
 				handled = true
 
 				if val != nil {
-					tokens.Push(typ, val...)
+					tokens.PushAt(pos, typ, val...)
 				}
 				break
 			}
@@ -197,15 +310,58 @@ func Tokenize(content []rune) TokenList {
 			continue
 		}
 
-		tokens.Push(Text, stream...)
+		tokens.PushAt(pos, utils.If(slices.Contains(Keywords, string(stream)), Keyword, Ident), stream...)
 	}
 
-	tokens.Push(EOF, 0)
+	tokens.PushAt(cursor.Position(), EOF, 0)
 
 	return tokens
 }
 
 // #region Utilities
+
+// Returns true when the last token on the current line is a value that an operator can follow
+//
+//	a // 2 // "//" divides
+//	{ // 2 // "//" starts a comment
+func endsWithOperand(tokens TokenList) bool {
+	if len(tokens) == 0 {
+		return false
+	}
+
+	var last = tokens[len(tokens)-1]
+	switch last.Typ {
+	case Ident, Number, Builtin, String, Color, RoundR, SquareR:
+		return true
+	case Keyword:
+		return slices.Contains([]string{"true", "false", "null"}, string(last.Val))
+	}
+
+	return false
+}
+
+// Returns the longest operator that starts at the cursor, or an empty string if there is none
+func matchOperator(cursor *Cursor) (longest string) {
+	for _, op := range operators {
+		if len(op) <= len(longest) {
+			continue
+		}
+
+		var matches = true
+		for i, char := range op {
+			if cursor.PeekOffset(i) != char {
+				matches = false
+				break
+			}
+		}
+
+		if matches {
+			longest = op
+		}
+	}
+
+	return longest
+}
 func formatError(message string, char rune, line int, column int) string {
 	return fmt.Sprintf(color.InRed("%s \"%s\" at %s:%s"), message, string(char), color.InYellow(line), color.InYellow(column))
 }
@@ -1,7 +1,19 @@
 package types
 
+import "fmt"
+
 const (
 	// End Of Transmission.
 	// Used as an End Of File (EOF) indicator character
 	EOT = 0x00
 )
+
+// A location in the source file, both line and column start at 1
+type Position struct {
+	Line   int
+	Column int
+}
+
+func (this Position) String() string {
+	return fmt.Sprintf("%d:%d", this.Line, this.Column)
+}